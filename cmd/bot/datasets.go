@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+	"github.com/urfave/cli/v2"
+)
+
+// openDatasetManager opens the datastore and checks that it supports
+// managing multiple dataset versions.
+func openDatasetManager(c *cli.Context) (database.SynonymStore, database.DatasetManager, error) {
+	store, err := database.New(c.Context, c.String("datastore"), database.Options{
+		Timeout:   time.Duration(c.Int("timeout")) * time.Second,
+		KeyPrefix: c.String("key-prefix"),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manager, ok := store.(database.DatasetManager)
+	if !ok {
+		store.Close()
+		return nil, nil, errors.New("datastore does not support versioned datasets")
+	}
+
+	return store, manager, nil
+}
+
+func listDatasets(c *cli.Context) error {
+	store, manager, err := openDatasetManager(c)
+	if err != nil {
+		return err
+	}
+
+	defer store.Close()
+
+	datasets, err := manager.ListDatasets(c.Context)
+	if err != nil {
+		return err
+	}
+
+	var active int64
+	if info, err := store.GetDatasetInfo(c.Context); err == nil {
+		active = info.Version
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tWORDS\tLOADED\tACTIVE")
+
+	for _, info := range datasets {
+		var marker string
+		if info.Version == active {
+			marker = "*"
+		}
+
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\n", info.Version, info.Words, info.LoadedAt.Format(time.RFC3339), marker)
+	}
+
+	return w.Flush()
+}
+
+func activateDataset(c *cli.Context) error {
+	version, err := strconv.ParseInt(c.Args().First(), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid dataset version '%s'", c.Args().First())
+	}
+
+	store, manager, err := openDatasetManager(c)
+	if err != nil {
+		return err
+	}
+
+	defer store.Close()
+
+	if err := manager.ActivateDataset(c.Context, version); err != nil {
+		return err
+	}
+
+	fmt.Printf("Activated dataset version %d\n", version)
+
+	return nil
+}
+
+func pruneDatasets(c *cli.Context) error {
+	store, manager, err := openDatasetManager(c)
+	if err != nil {
+		return err
+	}
+
+	defer store.Close()
+
+	versions, err := manager.PruneDatasets(c.Context, c.Int("keep"))
+	if err != nil {
+		return err
+	}
+
+	for _, v := range versions {
+		fmt.Printf("Pruned dataset version %d\n", v)
+	}
+
+	if c.Bool("legacy") {
+		count, err := manager.PruneLegacyKeys(c.Context)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Pruned %d unversioned keys\n", count)
+	}
+
+	return nil
+}
+
+var datasetsCommand = &cli.Command{
+	Name:        "datasets",
+	Usage:       "Manage dataset versions",
+	Description: "List, activate and prune versions of the dataset loaded into the datastore",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "datastore",
+			Aliases:  []string{"s"},
+			Usage:    "URI of Redis datastore. Formatted like redis[s][-sentinel|-cluster]://[user:password@]<address>:<port>[/db]",
+			Required: true,
+		},
+		&cli.IntFlag{
+			Name:    "timeout",
+			Aliases: []string{"w"},
+			Usage:   "How long to keep retrying the connection to the database in seconds",
+			Value:   30,
+		},
+		keyPrefixFlag,
+	},
+	Subcommands: []*cli.Command{
+		{
+			Name:   "list",
+			Usage:  "List all loaded dataset versions",
+			Action: listDatasets,
+		},
+		{
+			Name:      "activate",
+			Usage:     "Switch the bot over to a dataset version",
+			ArgsUsage: "<version>",
+			Action:    activateDataset,
+		},
+		{
+			Name:   "prune",
+			Usage:  "Delete inactive dataset versions",
+			Action: pruneDatasets,
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "keep",
+					Usage: "Number of the most recent inactive versions to keep for rolling back",
+					Value: 1,
+				},
+				&cli.BoolFlag{
+					Name:  "legacy",
+					Usage: "Also delete every noun, verb, adj and adv key within the key prefix left behind by releases without dataset versions",
+				},
+			},
+		},
+	},
+}
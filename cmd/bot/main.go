@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/MrFlynn/thesaurize/internal/database"
 	"github.com/MrFlynn/thesaurize/internal/discord"
 	"github.com/MrFlynn/thesaurize/internal/loader"
 	"github.com/urfave/cli/v2"
@@ -19,7 +25,56 @@ Commit:   %s
 Report issues to https://github.com/MrFlynn/thesaurize
 `
 
+// Flag selecting the key namespace, shared by every command that connects to
+// the datastore.
+var keyPrefixFlag = &cli.StringFlag{
+	Name:    "key-prefix",
+	Usage:   "Prefix for all keys and channels in Redis so multiple bots can share one datastore",
+	EnvVars: []string{"THESAURIZE_KEY_PREFIX"},
+}
+
+// Flags shared by every command that loads a dataset.
+var profanityFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:    "skip-profane-words",
+		Aliases: []string{"p"},
+		Value:   true,
+		Usage:   "Skip profane words when loading data into the datastore",
+	},
+	&cli.StringSliceFlag{
+		Name:  "profane-word-categories",
+		Usage: "Categories of profane words to skip (general, lgbtq, racial, religious, sexual, and/or shock)",
+		Value: cli.NewStringSlice("lgbtq", "racial", "religious"),
+	},
+	&cli.StringFlag{
+		Name:  "profane-word-index-url",
+		Usage: "Index of profane words",
+		Value: "https://raw.githubusercontent.com/dsojevic/profanity-list/refs/heads/main/en.json",
+	},
+}
+
+// exitCode maps errors to distinct exit codes so that failures can be told
+// apart by whatever is supervising the process.
+func exitCode(err error) int {
+	var (
+		loadErr *loader.Error
+		connErr *database.ConnectionError
+		uriErr  *database.URIError
+	)
+
+	switch {
+	case errors.As(err, &loadErr):
+		return 10 + int(loadErr.Kind)
+	case errors.As(err, &connErr), errors.As(err, &uriErr):
+		return 10 + int(loader.ConnectionError)
+	default:
+		return 1
+	}
+}
+
 func main() {
+	rand.Seed(time.Now().UnixNano())
+
 	compiled, err := time.Parse(time.RFC3339, date)
 	if err != nil {
 		compiled = time.Now()
@@ -45,7 +100,7 @@ func main() {
 
 					return discord.Run(c, skip)
 				},
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:     "token",
 						Aliases:  []string{"t"},
@@ -56,25 +111,46 @@ func main() {
 					&cli.StringFlag{
 						Name:     "datastore",
 						Aliases:  []string{"s"},
-						Usage:    "URI of Redis datastore. Formatted like redis://<address>:<port>",
+						Usage:    "URI of datastore. Formatted like redis[s][-sentinel|-cluster]://[user:password@]<address>:<port>[/db], file:///<path> or memory://",
 						Required: true,
 					},
 					&cli.IntFlag{
 						Name:    "timeout",
 						Aliases: []string{"w"},
-						Usage:   "How long to wait for the database in seconds. A value of 0 will skip this check",
+						Usage:   "How long to wait for the database to connect and become ready in seconds. A value of 0 will skip the ready check",
 						Value:   30,
 					},
-				},
+					keyPrefixFlag,
+					&cli.StringFlag{
+						Name:    "data",
+						Aliases: []string{"d"},
+						Usage:   "OpenOffice thesaurus data file to load before starting. Required for memory://",
+					},
+					&cli.IntFlag{
+						Name:  "cache-size",
+						Usage: "Number of words to keep synonyms of in the in-process cache. A value of 0 disables the cache",
+						Value: 10000,
+					},
+					&cli.DurationFlag{
+						Name:  "cache-ttl",
+						Usage: "How long synonyms are kept in the in-process cache",
+						Value: time.Hour,
+					},
+					&cli.Float64Flag{
+						Name:  "absurdity",
+						Usage: "Default for how obscure chosen synonyms are, from 0 (subtly off) through 0.5 (ignore weights) to 1 (maximally absurd)",
+						Value: float64(database.Balanced),
+					},
+				}, profanityFlags...),
 			},
 			{
 				Name:        "load",
-				Usage:       "Load data into Redis backend",
-				Description: "Load data from an OpenOffice thesaurus file into Redis",
+				Usage:       "Load data into datastore backend",
+				Description: "Load data from an OpenOffice thesaurus file into the datastore",
 				Action: func(ctx *cli.Context) error {
 					return loader.Load(ctx)
 				},
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:     "data",
 						Aliases:  []string{"d"},
@@ -84,27 +160,23 @@ func main() {
 					&cli.StringFlag{
 						Name:     "datastore",
 						Aliases:  []string{"s"},
-						Usage:    "URI of Redis datastore. Formatted like redis://<address>:<port>",
+						Usage:    "URI of datastore. Formatted like redis[s][-sentinel|-cluster]://[user:password@]<address>:<port>[/db] or file:///<path>",
 						Required: true,
 					},
-					&cli.BoolFlag{
-						Name:    "skip-profane-words",
-						Aliases: []string{"p"},
-						Value:   true,
-						Usage:   "Skip profane words when loading data into redis",
-					},
-					&cli.StringSliceFlag{
-						Name:  "profane-word-categories",
-						Usage: "Categories of profane words to skip (general, lgbtq, racial, religious, sexual, and/or shock)",
-						Value: cli.NewStringSlice("lgbtq", "racial", "religious"),
+					&cli.IntFlag{
+						Name:    "timeout",
+						Aliases: []string{"w"},
+						Usage:   "How long to keep retrying the connection to the database in seconds",
+						Value:   30,
 					},
-					&cli.StringFlag{
-						Name:  "profane-word-index-url",
-						Usage: "Index of profane words",
-						Value: "https://raw.githubusercontent.com/dsojevic/profanity-list/refs/heads/main/en.json",
+					keyPrefixFlag,
+					&cli.BoolFlag{
+						Name:  "skip-if-loaded",
+						Usage: "Skip loading if the datastore already contains a complete dataset",
 					},
-				},
+				}, profanityFlags...),
 			},
+			datasetsCommand,
 			{
 				Name:        "info",
 				Usage:       "Get more detailed information about the bot",
@@ -123,7 +195,12 @@ func main() {
 		},
 		// Exit handler.
 		ExitErrHandler: func(context *cli.Context, err error) {
-			log.Fatalf("Application ran into fatal error: %s", err)
+			if err == nil {
+				return
+			}
+
+			log.Printf("Application ran into fatal error: %s", err)
+			os.Exit(exitCode(err))
 		},
 		// App information.
 		Version:  version,
@@ -139,5 +216,15 @@ func main() {
 		},
 	}
 
-	app.Run(os.Args)
+	// Cancelled on the first interrupt so that commands can shut down
+	// cleanly. A second interrupt kills the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	app.RunContext(ctx, os.Args)
 }
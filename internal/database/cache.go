@@ -0,0 +1,255 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// How often the cache checks whether a different dataset has been activated.
+const versionCheckInterval = 10 * time.Second
+
+// CacheStats holds counters describing how effective the cache has been.
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+func (c CacheStats) String() string {
+	var ratio float64
+	if total := c.Hits + c.Misses; total > 0 {
+		ratio = float64(c.Hits) / float64(total) * 100
+	}
+
+	return fmt.Sprintf(
+		"%d hits, %d misses (%.1f%% hit rate), %d evictions, %d entries",
+		c.Hits,
+		c.Misses,
+		ratio,
+		c.Evictions,
+		c.Size,
+	)
+}
+
+type cacheEntry struct {
+	key     string
+	sets    SynonymSets
+	expires time.Time
+}
+
+// CachedStore wraps a SynonymStore with a bounded, in-process LRU cache of the
+// full synonym sets, and sets of other related words, of each word. Random
+// selection of a synonym then happens locally. Entries expire after a TTL and
+// the whole cache is invalidated when a different dataset version becomes
+// active.
+type CachedStore struct {
+	SynonymStore
+
+	size int
+	ttl  time.Duration
+
+	mu           sync.Mutex
+	entries      map[string]*list.Element
+	order        *list.List
+	version      int64
+	versionCheck time.Time
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// NewCachedStore creates a cache holding the synonyms of at most size words in
+// front of store. Each entry is kept for at most ttl.
+func NewCachedStore(store SynonymStore, size int, ttl time.Duration) *CachedStore {
+	return &CachedStore{
+		SynonymStore: store,
+		size:         size,
+		ttl:          ttl,
+		entries:      make(map[string]*list.Element, size),
+		order:        list.New(),
+	}
+}
+
+// Stats returns the current cache counters.
+func (c *CachedStore) Stats() CacheStats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:      atomic.LoadUint64(&c.hits),
+		Misses:    atomic.LoadUint64(&c.misses),
+		Evictions: atomic.LoadUint64(&c.evictions),
+		Size:      size,
+	}
+}
+
+// Purge removes every entry from the cache.
+func (c *CachedStore) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.purge()
+}
+
+func (c *CachedStore) purge() {
+	c.entries = make(map[string]*list.Element, c.size)
+	c.order.Init()
+}
+
+// checkVersion purges the cache if a different dataset has been activated
+// since the last check. The underlying store is asked at most once every
+// versionCheckInterval.
+func (c *CachedStore) checkVersion(ctx context.Context) {
+	c.mu.Lock()
+	if time.Now().Before(c.versionCheck) {
+		c.mu.Unlock()
+		return
+	}
+
+	c.versionCheck = time.Now().Add(versionCheckInterval)
+	c.mu.Unlock()
+
+	info, err := c.SynonymStore.GetDatasetInfo(ctx)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if info.Version != c.version {
+		if c.version != 0 {
+			log.Printf("Dataset version changed from %d to %d, clearing cache", c.version, info.Version)
+		}
+
+		c.version = info.Version
+		c.purge()
+	}
+}
+
+// cacheKey returns the key of the words with the given relation to word.
+func cacheKey(word string, relation Relation) string {
+	if relation == Synonymous {
+		return word
+	}
+
+	return fmt.Sprintf("%s:%s", relationKeyMap[relation], word)
+}
+
+func (c *CachedStore) get(key string, now time.Time) (SynonymSets, bool) {
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*cacheEntry)
+	if now.After(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+
+	return entry.sets, true
+}
+
+func (c *CachedStore) add(key string, sets SynonymSets, now time.Time) {
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = &cacheEntry{key: key, sets: sets, expires: now.Add(c.ttl)}
+		c.order.MoveToFront(elem)
+
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, sets: sets, expires: now.Add(c.ttl)})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+
+		atomic.AddUint64(&c.evictions, 1)
+	}
+}
+
+// GetSynonymSets returns the synonym sets of each word, only looking up words
+// missing from the cache in the underlying store.
+func (c *CachedStore) GetSynonymSets(ctx context.Context, words []string) (map[string]SynonymSets, error) {
+	return c.GetRelatedSets(ctx, words, Synonymous)
+}
+
+// GetRelatedSets returns the sets of related words of each word, only looking
+// up words missing from the cache in the underlying store.
+func (c *CachedStore) GetRelatedSets(ctx context.Context, words []string, relation Relation) (map[string]SynonymSets, error) {
+	c.checkVersion(ctx)
+
+	var (
+		unique  = uniqueWords(words)
+		results = make(map[string]SynonymSets, len(unique))
+		missing = make([]string, 0, len(unique))
+		now     = time.Now()
+	)
+
+	c.mu.Lock()
+	for _, word := range unique {
+		if sets, ok := c.get(cacheKey(word, relation), now); ok {
+			results[word] = sets
+		} else {
+			missing = append(missing, word)
+		}
+	}
+	c.mu.Unlock()
+
+	atomic.AddUint64(&c.hits, uint64(len(unique)-len(missing)))
+	atomic.AddUint64(&c.misses, uint64(len(missing)))
+
+	if len(missing) == 0 {
+		return results, nil
+	}
+
+	fetched, err := c.SynonymStore.GetRelatedSets(ctx, missing, relation)
+	if err != nil {
+		return results, err
+	}
+
+	c.mu.Lock()
+	for word, sets := range fetched {
+		c.add(cacheKey(word, relation), sets, now)
+		results[word] = sets
+	}
+	c.mu.Unlock()
+
+	return results, nil
+}
+
+// GetSynonyms returns all synonyms of word for the given lexeme.
+func (c *CachedStore) GetSynonyms(ctx context.Context, word string, lexeme Lexeme) ([]string, error) {
+	sets, err := c.GetSynonymSets(ctx, []string{word})
+	if err != nil {
+		return nil, err
+	}
+
+	if synonyms := sets[word][lexeme]; synonyms != nil {
+		return synonymWords(synonyms), nil
+	}
+
+	return []string{}, nil
+}
+
+// SendReady marks the dataset as loaded and clears the cache.
+func (c *CachedStore) SendReady(ctx context.Context, words int) (DatasetInfo, error) {
+	info, err := c.SynonymStore.SendReady(ctx, words)
+	c.Purge()
+
+	return info, err
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// countingStore counts how many words are looked up in the underlying store.
+type countingStore struct {
+	*MemoryStore
+	lookups int
+}
+
+func (c *countingStore) GetRelatedSets(ctx context.Context, words []string, relation Relation) (map[string]SynonymSets, error) {
+	c.lookups += len(words)
+	return c.MemoryStore.GetRelatedSets(ctx, words, relation)
+}
+
+func newCountingStore(t *testing.T) *countingStore {
+	t.Helper()
+
+	store := &countingStore{MemoryStore: NewMemoryStore()}
+	err := store.AddSynonyms(context.Background(), []Entry{
+		{Word: "big", Lexeme: Adjective, Synonyms: []Synonym{{"large", DefaultWeight}}},
+		{Word: "dog", Lexeme: Noun, Synonyms: []Synonym{{"hound", DefaultWeight}}},
+		{Word: "cat", Lexeme: Noun, Synonyms: []Synonym{{"feline", DefaultWeight}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.SendReady(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func TestCachedStoreHits(t *testing.T) {
+	store := newCountingStore(t)
+	cache := NewCachedStore(store, 10, time.Minute)
+
+	first := BestCandidateWords(context.Background(), cache, []string{"big", "dog", "dog", "walk"}, Balanced)
+	second := BestCandidateWords(context.Background(), cache, []string{"big", "dog"}, Balanced)
+
+	if first["big"] != "large" || first["dog"] != "hound" || first["walk"] != "walk" {
+		t.Errorf("Unexpected candidates %+v", first)
+	}
+
+	if second["big"] != "large" || second["dog"] != "hound" {
+		t.Errorf("Unexpected candidates %+v", second)
+	}
+
+	if store.lookups != 3 {
+		t.Errorf("Expected 3 lookups\n Got %d", store.lookups)
+	}
+
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 3 || stats.Size != 3 {
+		t.Errorf("Unexpected cache statistics %s", stats)
+	}
+}
+
+func TestCachedStoreEviction(t *testing.T) {
+	store := newCountingStore(t)
+	cache := NewCachedStore(store, 2, time.Minute)
+
+	cache.GetSynonymSets(context.Background(), []string{"big", "dog"})
+	cache.GetSynonymSets(context.Background(), []string{"big"})
+	cache.GetSynonymSets(context.Background(), []string{"cat"})
+
+	// Dog was the least recently used word so it was evicted.
+	cache.GetSynonymSets(context.Background(), []string{"big", "dog"})
+
+	if store.lookups != 4 {
+		t.Errorf("Expected 4 lookups\n Got %d", store.lookups)
+	}
+
+	if stats := cache.Stats(); stats.Evictions != 2 || stats.Size != 2 {
+		t.Errorf("Unexpected cache statistics %s", stats)
+	}
+}
+
+func TestCachedStoreExpiry(t *testing.T) {
+	store := newCountingStore(t)
+	cache := NewCachedStore(store, 10, 0)
+
+	cache.GetSynonymSets(context.Background(), []string{"dog"})
+	time.Sleep(time.Millisecond)
+	cache.GetSynonymSets(context.Background(), []string{"dog"})
+
+	if store.lookups != 2 {
+		t.Errorf("Expected 2 lookups\n Got %d", store.lookups)
+	}
+}
+
+func TestCachedStoreInvalidation(t *testing.T) {
+	store := newCountingStore(t)
+	cache := NewCachedStore(store, 10, time.Minute)
+
+	cache.GetSynonymSets(context.Background(), []string{"dog"})
+
+	if _, err := cache.SendReady(context.Background(), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	cache.GetSynonymSets(context.Background(), []string{"dog"})
+
+	if store.lookups != 2 {
+		t.Errorf("Expected 2 lookups\n Got %d", store.lookups)
+	}
+}
@@ -1,118 +1,224 @@
 package database
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"strings"
 	"time"
-
-	"github.com/go-redis/redis/v7"
 )
 
-const joinedServerKey = "servers"
+// Entry is a single set of weighted synonyms, or other related words, for a
+// word under a given lexeme. It is the unit of data used when bulk loading a
+// SynonymStore.
+type Entry struct {
+	Word     string
+	Lexeme   Lexeme
+	Relation Relation
+	Synonyms []Synonym
+}
+
+// SynonymStore is the interface implemented by every thesaurus backend. The
+// loader, transformer and bot only ever depend on this interface.
+type SynonymStore interface {
+	// GetSynonyms returns all synonyms of word for the given lexeme ordered
+	// by descending weight. A word without any synonyms returns an empty
+	// slice and no error.
+	GetSynonyms(ctx context.Context, word string, lexeme Lexeme) ([]string, error)
+	// GetSynonymSets returns the weighted synonyms of every lexeme for each
+	// word in a single batch. Words without any synonyms map to empty sets.
+	GetSynonymSets(ctx context.Context, words []string) (map[string]SynonymSets, error)
+	// GetRelatedSets is like GetSynonymSets, but returns the words with the
+	// given relation to each word instead of its synonyms.
+	GetRelatedSets(ctx context.Context, words []string, relation Relation) (map[string]SynonymSets, error)
+	// AddSynonyms inserts a batch of entries into the store. Synonyms for
+	// an existing word and lexeme are merged with the existing set, with
+	// the weights of synonyms already present being replaced.
+	AddSynonyms(ctx context.Context, entries []Entry) error
+	// SendReady marks the dataset as fully loaded by persisting a new
+	// readiness record containing the number of head words loaded.
+	SendReady(ctx context.Context, words int) (DatasetInfo, error)
+	// GetDatasetInfo returns the readiness record of the loaded dataset,
+	// or ErrNotReady if loading has not completed yet.
+	GetDatasetInfo(ctx context.Context) (DatasetInfo, error)
+	// WaitForReady blocks until the dataset has been marked as loaded or
+	// timeout seconds have passed. A timeout of 0 skips the check.
+	WaitForReady(ctx context.Context, timeout int) error
+	// Close releases any resources held by the store.
+	Close() error
+}
 
-// Database type acts as the control interface for the Redis datastore.
-type Database struct {
-	uri    string
-	client *redis.Client
+// Options configures how a synonym store connects to its backend.
+type Options struct {
+	// Timeout is how long to keep retrying the connection to remote
+	// backends.
+	Timeout time.Duration
+	// KeyPrefix is prepended to every key and channel name so that multiple
+	// bots can share one Redis datastore. Other backends ignore it.
+	KeyPrefix string
 }
 
-// New creates a database connection.
-func New(uri string) Database {
-	if strings.HasPrefix(uri, "redis://") {
-		uri = uri[8:]
+// New creates a synonym store from a datastore URI. The URI scheme selects
+// the backend: memory:// for an in-memory store, file:// for an embedded file
+// store and redis:// for Redis.
+func New(ctx context.Context, uri string, opts Options) (SynonymStore, error) {
+	switch {
+	case strings.HasPrefix(uri, "memory://"):
+		return NewMemoryStore(), nil
+	case strings.HasPrefix(uri, "file://"):
+		return NewFileStore(uri[7:])
+	default:
+		return NewRedisStore(ctx, uri, opts)
 	}
+}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:     uri,
-		Password: "",
-		DB:       0,
-	})
+const (
+	initialBackoff = 250 * time.Millisecond
+	maxBackoff     = 5 * time.Second
+)
 
-	_, err := client.Ping().Result()
-	if err != nil {
-		log.Fatalf("Could not connect to database: %s due to err: %s", uri, err)
-	}
+// retry calls fn until it succeeds, timeout has passed or ctx is cancelled,
+// doubling the delay between attempts each time. fn is always called at
+// least once.
+func retry(ctx context.Context, timeout time.Duration, fn func() error) error {
+	var (
+		deadline = time.Now().Add(timeout)
+		backoff  = initialBackoff
+	)
 
-	log.Printf("Connected to database at %s", uri)
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
 
-	return Database{
-		uri:    uri,
-		client: client,
-	}
-}
+		if time.Now().Add(backoff).After(deadline) {
+			return err
+		}
 
-// GetPipeline returns a TxPipeline from the underlying client. It is the
-// responsibility of the caller to execute the pipeline or close it.
-func (d *Database) GetPipeline() redis.Pipeliner {
-	return d.client.TxPipeline()
-}
+		log.Printf("Datastore not available, retrying in %s: %s", backoff, err)
 
-// GetBestCandidateWord returns the best replacement synonym for supplied word.
-// The return order should be in the defined lexeme order in Lexeme.go.
-func (d *Database) GetBestCandidateWord(word string) string {
-	results := make([]*redis.StringCmd, 4)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 
-	_, err := d.client.TxPipelined(func(pipe redis.Pipeliner) error {
-		pipe.Expire(fmt.Sprintf("best_word_single_%s", word), 10*time.Second)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
 
-		for idx, l := range ordering {
-			res := pipe.SRandMember(fmt.Sprintf("%s:%s", l, word))
-			results[idx] = res
+// uniqueWords returns words with all duplicates removed, preserving the order
+// in which they first appear.
+func uniqueWords(words []string) []string {
+	var (
+		seen   = make(map[string]struct{}, len(words))
+		unique = make([]string, 0, len(words))
+	)
+
+	for _, w := range words {
+		if _, ok := seen[w]; ok {
+			continue
 		}
 
-		return nil
-	})
+		seen[w] = struct{}{}
+		unique = append(unique, w)
+	}
+
+	return unique
+}
+
+// SynonymSets holds all synonyms of a single word grouped by lexeme, each
+// ordered by descending weight. Lexemes without any synonyms are left out.
+type SynonymSets map[Lexeme][]Synonym
 
-	if err != redis.Nil && err != nil {
-		log.Printf("Could not access datastore for word: %s, %s", word, err)
-		return word
+// Best samples a synonym by weight from the first lexeme (in the order
+// defined in lexeme.go) that has any synonyms.
+func (s SynonymSets) Best(absurdity Absurdity) (string, bool) {
+	if l, ok := s.Lexeme(); ok {
+		return s.Pick(l, absurdity)
 	}
 
-	for _, c := range results {
-		w, err := c.Result()
-		if err == nil {
-			return w
+	return "", false
+}
+
+// Lexeme returns the first lexeme (in the order defined in lexeme.go) that
+// has any synonyms.
+func (s SynonymSets) Lexeme() (Lexeme, bool) {
+	for _, l := range ordering {
+		if len(s[l]) > 0 {
+			return l, true
 		}
 	}
 
-	// Fallback. Only return if nothing was found.
-	return word
+	return 0, false
 }
 
-const (
-	statusChannelName = "status"
-	readyMessage      = "ready"
-)
+// Pick samples a synonym by weight from the given lexeme only.
+func (s SynonymSets) Pick(lexeme Lexeme, absurdity Absurdity) (string, bool) {
+	if synonyms := s[lexeme]; len(synonyms) > 0 {
+		return absurdity.pick(synonyms), true
+	}
 
-// SendReady sends the ready message on the status channel.
-func (d *Database) SendReady() error {
-	return d.client.Publish(statusChannelName, readyMessage).Err()
+	return "", false
 }
 
-// WaitForReady waits for `ready` status message in `status` pubsub channel.
-func (d *Database) WaitForReady(timeout int) error {
-	if timeout == 0 {
-		log.Println("Skipping database check...")
-		return nil
-	}
+// collectSynonymSets looks up the sets of words with the given relation to
+// every unique word one key at a time using lookup. It is used by stores
+// where individual lookups are cheap. Lookups stop as soon as ctx is done.
+func collectSynonymSets(ctx context.Context, lookup func(key string) ([]Synonym, error), words []string, relation Relation) (map[string]SynonymSets, error) {
+	results := make(map[string]SynonymSets, len(words))
 
-	pubsub := d.client.Subscribe(statusChannelName)
-	defer pubsub.Close()
+	for _, word := range uniqueWords(words) {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
 
-	log.Printf("Waiting for ready status on channel '%s' for %ds", statusChannelName, timeout)
+		sets := make(SynonymSets)
 
-	ch := pubsub.Channel()
-	for {
-		select {
-		case msg := <-ch:
-			if msg.Payload == readyMessage {
-				return nil
-			} else {
-				return fmt.Errorf("got unexpected status message '%s' on ready channel", msg.Payload)
+		for _, l := range ordering {
+			synonyms, err := lookup(relationKey(word, l, relation))
+			if err != nil {
+				return nil, err
+			}
+
+			if len(synonyms) > 0 {
+				sets[l] = synonyms
 			}
-		case <-time.After(time.Duration(timeout) * time.Second):
-			return fmt.Errorf("Channel '%s timed out after %ds", statusChannelName, timeout)
 		}
+
+		results[word] = sets
 	}
+
+	return results, nil
+}
+
+// BestCandidateWords returns the best replacement synonym for every word in
+// store, trying each lexeme in the order defined in lexeme.go and sampling by
+// weight according to absurdity. Repeated words are only looked up once and
+// words without any synonyms are mapped to themselves.
+func BestCandidateWords(ctx context.Context, store SynonymStore, words []string, absurdity Absurdity) map[string]string {
+	sets, err := store.GetRelatedSets(ctx, words, Synonymous)
+	if err != nil {
+		log.Printf("Could not access datastore for %d words, %s", len(words), err)
+	}
+
+	results := make(map[string]string, len(words))
+
+	for _, word := range words {
+		if candidate, ok := sets[word].Best(absurdity); ok {
+			results[word] = candidate
+		} else {
+			// Fallback. Only used if nothing was found.
+			results[word] = word
+		}
+	}
+
+	return results
+}
+
+// BestCandidateWord returns the best replacement synonym for a single word.
+func BestCandidateWord(ctx context.Context, store SynonymStore, word string, absurdity Absurdity) string {
+	return BestCandidateWords(ctx, store, []string{word}, absurdity)[word]
 }
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotReady is returned when no dataset has been fully loaded into a store.
+var ErrNotReady = errors.New("dataset has not been loaded")
+
+// ErrUnknownDataset is returned when activating a dataset version that has
+// not been fully loaded.
+var ErrUnknownDataset = errors.New("unknown dataset version")
+
+// ErrEmptyDataset is returned when marking a dataset as ready without having
+// added any entries to it.
+var ErrEmptyDataset = errors.New("dataset has no entries")
+
+// DatasetInfo is the persisted readiness record of a fully loaded dataset.
+type DatasetInfo struct {
+	// Version is incremented every time a dataset is loaded.
+	Version int64 `json:"version"`
+	// Words is the number of head words in the dataset.
+	Words int `json:"words"`
+	// LoadedAt is when loading the dataset completed.
+	LoadedAt time.Time `json:"loaded_at"`
+}
+
+// DatasetManager is implemented by stores that keep multiple versions of
+// the dataset side by side and can switch between them.
+type DatasetManager interface {
+	// ListDatasets returns every fully loaded dataset ordered by version.
+	ListDatasets(ctx context.Context) ([]DatasetInfo, error)
+	// ActivateDataset atomically switches lookups over to the given
+	// dataset version.
+	ActivateDataset(ctx context.Context, version int64) error
+	// PruneDatasets deletes every dataset apart from the active one and the
+	// keep most recent inactive ones. It returns the pruned versions.
+	PruneDatasets(ctx context.Context, keep int) ([]int64, error)
+	// PruneLegacyKeys deletes the keys of the unversioned dataset written by
+	// releases that predate dataset versions. It returns the number of
+	// deleted keys.
+	PruneLegacyKeys(ctx context.Context) (int64, error)
+}
+
+func (d DatasetInfo) String() string {
+	return fmt.Sprintf(
+		"version %d with %d words loaded at %s",
+		d.Version,
+		d.Words,
+		d.LoadedAt.Format(time.RFC3339),
+	)
+}
@@ -0,0 +1,31 @@
+package database
+
+import "fmt"
+
+// URIError is returned when a datastore URI cannot be parsed. The URI itself
+// is left out of the message since it may contain credentials.
+type URIError struct {
+	Err error
+}
+
+func (e *URIError) Error() string {
+	return fmt.Sprintf("invalid datastore URI: %s", e.Err)
+}
+
+func (e *URIError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectionError is returned when a datastore cannot be reached or opened.
+type ConnectionError struct {
+	Addr string
+	Err  error
+}
+
+func (e *ConnectionError) Error() string {
+	return fmt.Sprintf("could not connect to datastore %s: %s", e.Addr, e.Err)
+}
+
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewInvalidURI(t *testing.T) {
+	_, err := New(context.Background(), "http://localhost:6379", Options{})
+
+	var uriErr *URIError
+	if !errors.As(err, &uriErr) {
+		t.Errorf("Expected URIError\n Got %v", err)
+	}
+}
+
+func TestNewUnreachableRedis(t *testing.T) {
+	_, err := New(context.Background(), "redis://127.0.0.1:1?dial_timeout=1", Options{})
+
+	var connErr *ConnectionError
+	if !errors.As(err, &connErr) {
+		t.Errorf("Expected ConnectionError\n Got %v", err)
+	}
+}
+
+func TestNewCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corrupt.db")
+	if err := ioutil.WriteFile(path, []byte("not a datastore file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := New(context.Background(), "file://"+path, Options{})
+
+	var connErr *ConnectionError
+	if !errors.As(err, &connErr) {
+		t.Errorf("Expected ConnectionError\n Got %v", err)
+	}
+}
@@ -0,0 +1,443 @@
+package database
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File layout:
+//
+//	header  magic (4 bytes) | format version (1 byte)
+//	data    synonym records, each a '|' separated list of alternating
+//	        synonyms and their weights, ordered by descending weight
+//	index   for each key in sorted order: uvarint key length | key |
+//	        uvarint record offset | uvarint record length
+//	trailer int64 dataset version | uint32 word count | int64 load time |
+//	        uint64 index offset | uint32 key count | magic (4 bytes)
+//
+// The '|' separator is safe to use since it is also the field separator in
+// the thesaurus data file, so no synonym can contain it.
+const (
+	fileMagic         = "THSZ"
+	fileFormatVersion = 3
+	fileHeaderSize    = len(fileMagic) + 1
+	fileTrailerSize   = 8 + 4 + 8 + 8 + 4 + len(fileMagic)
+	fileSeparator     = "|"
+)
+
+type fileSpan struct {
+	offset int64
+	length int64
+}
+
+// FileStore is a SynonymStore backed by a single indexed file on disk. The
+// index is kept in memory while the synonym records are read on demand. Since
+// loading replaces the file as a whole, the file is reopened once it has been
+// replaced by another process.
+type FileStore struct {
+	path string
+
+	mu    sync.RWMutex
+	file  *os.File
+	stat  os.FileInfo
+	index map[string]fileSpan
+	info  DatasetInfo
+
+	// When to check whether the file has been replaced next.
+	nextCheck time.Time
+
+	// Entries added by AddSynonyms. These are only written to disk once
+	// SendReady is called.
+	pending map[string][]Synonym
+}
+
+// NewFileStore opens the file store at path. The file does not need to exist
+// yet; it will be created once a dataset is loaded into it.
+func NewFileStore(path string) (*FileStore, error) {
+	store := &FileStore{path: path}
+
+	if err := store.open(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, &ConnectionError{Addr: path, Err: err}
+	}
+
+	return store, nil
+}
+
+func (f *FileStore) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	index, info, err := readFileIndex(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		f.file.Close()
+	}
+
+	f.file = file
+	f.stat = stat
+	f.index = index
+	f.info = info
+
+	log.Printf("Opened datastore file at %s containing dataset %s", f.path, info)
+
+	return nil
+}
+
+// refresh reopens the datastore file if it has been replaced since it was
+// opened. The file is checked at most once every versionCheckInterval.
+func (f *FileStore) refresh() {
+	f.mu.Lock()
+	if f.file == nil || time.Now().Before(f.nextCheck) {
+		f.mu.Unlock()
+		return
+	}
+
+	f.nextCheck = time.Now().Add(versionCheckInterval)
+	opened := f.stat
+	f.mu.Unlock()
+
+	stat, err := os.Stat(f.path)
+	if err != nil || os.SameFile(stat, opened) {
+		return
+	}
+
+	if err := f.open(); err != nil {
+		log.Printf("Could not reopen datastore file at %s, %s", f.path, err)
+	}
+}
+
+func readFileIndex(file *os.File) (map[string]fileSpan, DatasetInfo, error) {
+	var info DatasetInfo
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, info, err
+	}
+
+	if stat.Size() < int64(fileHeaderSize+fileTrailerSize) {
+		return nil, info, errors.New("file is too small to be a datastore")
+	}
+
+	header := make([]byte, fileHeaderSize)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return nil, info, err
+	}
+
+	if string(header[:len(fileMagic)]) != fileMagic {
+		return nil, info, errors.New("file is not a datastore")
+	}
+
+	if v := header[len(fileMagic)]; v != fileFormatVersion {
+		return nil, info, fmt.Errorf("unsupported datastore format version %d", v)
+	}
+
+	trailer := make([]byte, fileTrailerSize)
+	if _, err := file.ReadAt(trailer, stat.Size()-int64(fileTrailerSize)); err != nil {
+		return nil, info, err
+	}
+
+	if string(trailer[32:]) != fileMagic {
+		return nil, info, errors.New("datastore file is incomplete")
+	}
+
+	info.Version = int64(binary.BigEndian.Uint64(trailer[:8]))
+	info.Words = int(binary.BigEndian.Uint32(trailer[8:12]))
+	info.LoadedAt = time.Unix(int64(binary.BigEndian.Uint64(trailer[12:20])), 0).UTC()
+
+	var (
+		indexOffset = int64(binary.BigEndian.Uint64(trailer[20:28]))
+		count       = binary.BigEndian.Uint32(trailer[28:32])
+		indexSize   = stat.Size() - int64(fileTrailerSize) - indexOffset
+	)
+
+	rd := bufio.NewReader(io.NewSectionReader(file, indexOffset, indexSize))
+	index := make(map[string]fileSpan, count)
+
+	for i := uint32(0); i < count; i++ {
+		keyLen, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return nil, info, err
+		}
+
+		key := make([]byte, keyLen)
+		if _, err := io.ReadFull(rd, key); err != nil {
+			return nil, info, err
+		}
+
+		offset, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return nil, info, err
+		}
+
+		length, err := binary.ReadUvarint(rd)
+		if err != nil {
+			return nil, info, err
+		}
+
+		index[string(key)] = fileSpan{offset: int64(offset), length: int64(length)}
+	}
+
+	return index, info, nil
+}
+
+// GetSynonyms returns all synonyms of word for the given lexeme.
+func (f *FileStore) GetSynonyms(ctx context.Context, word string, lexeme Lexeme) ([]string, error) {
+	f.refresh()
+
+	synonyms, err := f.lookup(synonymKey(word, lexeme))
+	return synonymWords(synonyms), err
+}
+
+func (f *FileStore) lookup(key string) ([]Synonym, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.file == nil {
+		return nil, errors.New("datastore file has not been loaded")
+	}
+
+	span, ok := f.index[key]
+	if !ok {
+		return []Synonym{}, nil
+	}
+
+	record := make([]byte, span.length)
+	if _, err := f.file.ReadAt(record, span.offset); err != nil {
+		return nil, err
+	}
+
+	fields := strings.Split(string(record), fileSeparator)
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("malformed record for '%s'", key)
+	}
+
+	synonyms := make([]Synonym, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		weight, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			return nil, err
+		}
+
+		synonyms = append(synonyms, Synonym{Word: fields[i], Weight: weight})
+	}
+
+	return synonyms, nil
+}
+
+// GetSynonymSets returns the synonyms of every lexeme for each word.
+func (f *FileStore) GetSynonymSets(ctx context.Context, words []string) (map[string]SynonymSets, error) {
+	return f.GetRelatedSets(ctx, words, Synonymous)
+}
+
+// GetRelatedSets returns the related words of every lexeme for each word.
+func (f *FileStore) GetRelatedSets(ctx context.Context, words []string, relation Relation) (map[string]SynonymSets, error) {
+	f.refresh()
+
+	return collectSynonymSets(ctx, f.lookup, words, relation)
+}
+
+// AddSynonyms stages entries to be written to the file. Nothing is written to
+// disk until SendReady is called.
+func (f *FileStore) AddSynonyms(ctx context.Context, entries []Entry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.pending == nil {
+		f.pending = make(map[string][]Synonym)
+	}
+
+	for _, e := range entries {
+		key := relationKey(e.Word, e.Lexeme, e.Relation)
+		f.pending[key] = mergeSynonyms(f.pending[key], e.Synonyms)
+	}
+
+	return nil
+}
+
+// SendReady writes all staged entries along with the readiness record to a
+// new file and atomically replaces the existing datastore file with it.
+func (f *FileStore) SendReady(ctx context.Context, words int) (DatasetInfo, error) {
+	f.mu.Lock()
+	pending := f.pending
+	f.pending = nil
+
+	info := DatasetInfo{Version: f.info.Version + 1, Words: words, LoadedAt: time.Now().UTC()}
+	f.mu.Unlock()
+
+	tmpPath := f.path + ".tmp"
+	if err := writeFileStore(tmpPath, pending, info); err != nil {
+		os.Remove(tmpPath)
+		return DatasetInfo{}, err
+	}
+
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return DatasetInfo{}, err
+	}
+
+	return info, f.open()
+}
+
+// GetDatasetInfo returns the readiness record stored in the datastore file.
+func (f *FileStore) GetDatasetInfo(ctx context.Context) (DatasetInfo, error) {
+	f.refresh()
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.file == nil {
+		return DatasetInfo{}, ErrNotReady
+	}
+
+	return f.info, nil
+}
+
+func writeFileStore(path string, entries map[string][]Synonym, info DatasetInfo) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var (
+		wr     = bufio.NewWriter(file)
+		offset = int64(fileHeaderSize)
+		spans  = make([]fileSpan, len(keys))
+	)
+
+	wr.WriteString(fileMagic)
+	wr.WriteByte(fileFormatVersion)
+
+	for i, key := range keys {
+		fields := make([]string, 0, 2*len(entries[key]))
+		for _, s := range entries[key] {
+			fields = append(fields, s.Word, strconv.FormatFloat(s.Weight, 'g', -1, 64))
+		}
+
+		record := strings.Join(fields, fileSeparator)
+		if _, err := wr.WriteString(record); err != nil {
+			return err
+		}
+
+		spans[i] = fileSpan{offset: offset, length: int64(len(record))}
+		offset += int64(len(record))
+	}
+
+	var (
+		index = &bytes.Buffer{}
+		buf   = make([]byte, binary.MaxVarintLen64)
+	)
+
+	for i, key := range keys {
+		index.Write(buf[:binary.PutUvarint(buf, uint64(len(key)))])
+		index.WriteString(key)
+		index.Write(buf[:binary.PutUvarint(buf, uint64(spans[i].offset))])
+		index.Write(buf[:binary.PutUvarint(buf, uint64(spans[i].length))])
+	}
+
+	if _, err := index.WriteTo(wr); err != nil {
+		return err
+	}
+
+	trailer := make([]byte, fileTrailerSize)
+	binary.BigEndian.PutUint64(trailer[:8], uint64(info.Version))
+	binary.BigEndian.PutUint32(trailer[8:12], uint32(info.Words))
+	binary.BigEndian.PutUint64(trailer[12:20], uint64(info.LoadedAt.Unix()))
+	binary.BigEndian.PutUint64(trailer[20:28], uint64(offset))
+	binary.BigEndian.PutUint32(trailer[28:32], uint32(len(keys)))
+	copy(trailer[32:], fileMagic)
+
+	if _, err := wr.Write(trailer); err != nil {
+		return err
+	}
+
+	if err := wr.Flush(); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// WaitForReady waits for the datastore file to be created by the loader.
+func (f *FileStore) WaitForReady(ctx context.Context, timeout int) error {
+	if timeout == 0 {
+		log.Println("Skipping database check...")
+		return nil
+	}
+
+	f.mu.RLock()
+	loaded := f.file != nil
+	f.mu.RUnlock()
+
+	if loaded {
+		return nil
+	}
+
+	log.Printf("Waiting for datastore file '%s' for %ds", f.path, timeout)
+
+	deadline := time.After(time.Duration(timeout) * time.Second)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := f.open(); err == nil {
+				return nil
+			}
+		case <-deadline:
+			return fmt.Errorf("datastore file '%s' not ready after %ds", f.path, timeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close closes the datastore file.
+func (f *FileStore) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "thesaurus.db")
+
+	writer, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writer.AddSynonyms(context.Background(), []Entry{
+		{Word: "run", Lexeme: Verb, Synonyms: []Synonym{{"sprint", 2}, {"jog", 1}}},
+		{Word: "run", Lexeme: Noun, Synonyms: []Synonym{{"dash", 1}}},
+		{Word: "run", Lexeme: Verb, Synonyms: []Synonym{{"jog", 3}, {"race", 0.5}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := writer.SendReady(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	writer.Close()
+
+	reader, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer reader.Close()
+
+	if err := reader.WaitForReady(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := reader.GetDatasetInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Version != 1 || info.Words != 1 {
+		t.Errorf("Expected version 1 with 1 word\n Got %s", info)
+	}
+
+	synonyms, err := reader.GetSynonyms(context.Background(), "run", Verb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Synonyms are ordered by weight and the latest weight of jog wins.
+	if expected := []string{"jog", "sprint", "race"}; !cmp.Equal(synonyms, expected) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected, synonyms)
+	}
+
+	// Nouns take priority over verbs.
+	if w := BestCandidateWord(context.Background(), reader, "run", Balanced); w != "dash" {
+		t.Errorf("Expected \"dash\", got: %s", w)
+	}
+
+	if w := BestCandidateWord(context.Background(), reader, "walk", Balanced); w != "walk" {
+		t.Errorf("Expected \"walk\", got: %s", w)
+	}
+}
+
+func TestFileStoreRelations(t *testing.T) {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "thesaurus.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.Close()
+
+	err = store.AddSynonyms(context.Background(), []Entry{
+		{Word: "ill", Lexeme: Adjective, Synonyms: []Synonym{{"sick", 1}}},
+		{Word: "ill", Lexeme: Adjective, Relation: Antonym, Synonyms: []Synonym{{"well", 1}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.SendReady(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Antonyms are kept apart from synonyms.
+	for i := 0; i < 10; i++ {
+		if w := BestCandidateWord(context.Background(), store, "ill", Balanced); w != "sick" {
+			t.Fatalf("Expected \"sick\", got: %s", w)
+		}
+	}
+
+	sets, err := store.GetRelatedSets(context.Background(), []string{"ill"}, Antonym)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]SynonymSets{"ill": {Adjective: {{"well", 1}}}}
+	if !cmp.Equal(sets, expected) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected, sets)
+	}
+}
+
+func TestFileStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "thesaurus.db")
+
+	load := func(synonym string) {
+		writer, err := NewFileStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer writer.Close()
+
+		err = writer.AddSynonyms(context.Background(), []Entry{
+			{Word: "run", Lexeme: Verb, Synonyms: []Synonym{{synonym, 1}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := writer.SendReady(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	load("sprint")
+
+	reader, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer reader.Close()
+
+	if info, err := reader.GetDatasetInfo(context.Background()); err != nil || info.Version != 1 {
+		t.Fatalf("Expected version 1\n Got %s, %v", info, err)
+	}
+
+	load("jog")
+
+	// The file is checked at most once every versionCheckInterval.
+	reader.nextCheck = time.Time{}
+
+	info, err := reader.GetDatasetInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Version != 2 {
+		t.Errorf("Expected version 2\n Got %s", info)
+	}
+
+	synonyms, err := reader.GetSynonyms(context.Background(), "run", Verb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"jog"}; !cmp.Equal(synonyms, expected) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected, synonyms)
+	}
+}
+
+func TestFileStoreMissing(t *testing.T) {
+	store, err := NewFileStore(filepath.Join(t.TempDir(), "missing.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.GetDatasetInfo(context.Background()); err != ErrNotReady {
+		t.Errorf("Expected ErrNotReady\n Got %v", err)
+	}
+
+	if _, err := store.GetSynonyms(context.Background(), "run", Verb); err == nil {
+		t.Error("Expected error reading from missing datastore file")
+	}
+
+	if err := store.WaitForReady(context.Background(), 1); err == nil {
+		t.Error("Expected timeout waiting for missing datastore file")
+	}
+}
@@ -1,5 +1,7 @@
 package database
 
+import "fmt"
+
 // Lexeme type defines the part of speech associated with the word lookup.
 type Lexeme int
 
@@ -31,3 +33,15 @@ func (l Lexeme) String() string {
 
 	return databaseStringMap[l]
 }
+
+// ParseLexeme converts the string form of a lexeme (as used in the thesaurus
+// data file) back into a Lexeme.
+func ParseLexeme(s string) (Lexeme, error) {
+	for l, name := range databaseStringMap {
+		if name == s {
+			return l, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown lexeme '%s'", s)
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a SynonymStore that keeps the entire thesaurus in process
+// memory. It is intended for local development and testing.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	synonyms map[string][]Synonym
+	info     *DatasetInfo
+
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		synonyms: make(map[string][]Synonym),
+		ready:    make(chan struct{}),
+	}
+}
+
+// GetSynonyms returns all synonyms of word for the given lexeme.
+func (m *MemoryStore) GetSynonyms(ctx context.Context, word string, lexeme Lexeme) ([]string, error) {
+	synonyms, err := m.lookup(synonymKey(word, lexeme))
+	return synonymWords(synonyms), err
+}
+
+func (m *MemoryStore) lookup(key string) ([]Synonym, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	synonyms := m.synonyms[key]
+
+	result := make([]Synonym, len(synonyms))
+	copy(result, synonyms)
+
+	return result, nil
+}
+
+// GetSynonymSets returns the synonyms of every lexeme for each word.
+func (m *MemoryStore) GetSynonymSets(ctx context.Context, words []string) (map[string]SynonymSets, error) {
+	return m.GetRelatedSets(ctx, words, Synonymous)
+}
+
+// GetRelatedSets returns the related words of every lexeme for each word.
+func (m *MemoryStore) GetRelatedSets(ctx context.Context, words []string, relation Relation) (map[string]SynonymSets, error) {
+	return collectSynonymSets(ctx, m.lookup, words, relation)
+}
+
+// AddSynonyms adds all entries to the store.
+func (m *MemoryStore) AddSynonyms(ctx context.Context, entries []Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, e := range entries {
+		key := relationKey(e.Word, e.Lexeme, e.Relation)
+		m.synonyms[key] = mergeSynonyms(m.synonyms[key], e.Synonyms)
+	}
+
+	return nil
+}
+
+// SendReady marks the store as loaded.
+func (m *MemoryStore) SendReady(ctx context.Context, words int) (DatasetInfo, error) {
+	m.mu.Lock()
+
+	info := DatasetInfo{Version: 1, Words: words, LoadedAt: time.Now()}
+	if m.info != nil {
+		info.Version = m.info.Version + 1
+	}
+
+	m.info = &info
+	m.mu.Unlock()
+
+	m.readyOnce.Do(func() {
+		close(m.ready)
+	})
+
+	return info, nil
+}
+
+// GetDatasetInfo returns the readiness record of the loaded dataset.
+func (m *MemoryStore) GetDatasetInfo(ctx context.Context) (DatasetInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.info == nil {
+		return DatasetInfo{}, ErrNotReady
+	}
+
+	return *m.info, nil
+}
+
+// WaitForReady waits for the store to be marked as loaded.
+func (m *MemoryStore) WaitForReady(ctx context.Context, timeout int) error {
+	if timeout == 0 {
+		log.Println("Skipping database check...")
+		return nil
+	}
+
+	select {
+	case <-m.ready:
+		return nil
+	case <-time.After(time.Duration(timeout) * time.Second):
+		return fmt.Errorf("in-memory datastore not ready after %ds", timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close is a no-op for the in-memory store.
+func (m *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,383 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+const joinedServerKey = "servers"
+
+// RedisStore is a SynonymStore backed by a Redis datastore. The datastore
+// may be a single node, a Sentinel managed failover group or a cluster.
+type RedisStore struct {
+	addrs  []string
+	client redis.UniversalClient
+	prefix string
+
+	// Version of the dataset currently being loaded by this store.
+	mu      sync.Mutex
+	staging int64
+
+	// Version of the active dataset as last read, and when it has to be read
+	// again.
+	active      int64
+	activeCheck time.Time
+}
+
+// NewRedisStore creates a connection to a Redis datastore. See parseRedisURI
+// for the accepted URI formats. The connection is retried with exponential
+// backoff until the timeout in opts has passed or ctx is cancelled.
+func NewRedisStore(ctx context.Context, uri string, opts Options) (*RedisStore, error) {
+	config, err := parseRedisURI(uri)
+	if err != nil {
+		return nil, &URIError{Err: err}
+	}
+
+	store := &RedisStore{
+		addrs:  config.opts.Addrs,
+		client: config.newClient(),
+		prefix: opts.KeyPrefix,
+	}
+
+	addrs := strings.Join(store.addrs, ",")
+
+	err = retry(ctx, opts.Timeout, func() error {
+		return store.conn(ctx).Ping().Err()
+	})
+	if err != nil {
+		store.client.Close()
+		return nil, &ConnectionError{Addr: addrs, Err: err}
+	}
+
+	log.Printf("Connected to database at %s", addrs)
+
+	return store, nil
+}
+
+// conn returns a client whose commands are bound to ctx. Only deadlines are
+// respected by the underlying client, so commands in flight are not aborted
+// when ctx is cancelled without one.
+func (d *RedisStore) conn(ctx context.Context) redis.UniversalClient {
+	switch client := d.client.(type) {
+	case *redis.Client:
+		return client.WithContext(ctx)
+	case *redis.ClusterClient:
+		return client.WithContext(ctx)
+	default:
+		return client
+	}
+}
+
+// key returns the name of a key or channel within the configured namespace.
+func (d *RedisStore) key(name string) string {
+	return d.prefix + name
+}
+
+func synonymKey(word string, lexeme Lexeme) string {
+	return fmt.Sprintf("%s:%s", lexeme, word)
+}
+
+// Every dataset loaded into Redis is written under its own version namespace
+// so that reloading never touches the data currently being served. The keys
+// describing the datasets share a hash tag so that they can be updated
+// together in a transaction when running against a cluster. Every key of a
+// dataset is also listed in a set within its namespace, so that it can be
+// deleted without scanning for its keys.
+const (
+	activeVersionKey   = "{dataset}:active"
+	nextVersionKey     = "{dataset}:next"
+	versionsKey        = "{dataset}:versions"
+	loadingVersionsKey = "{dataset}:loading"
+	datasetKeysKey     = "keys"
+)
+
+func versionedKey(version int64, key string) string {
+	return fmt.Sprintf("v%d:%s", version, key)
+}
+
+// activeVersion returns the version of the dataset currently being served so
+// that lookups don't need a round trip of their own to find it. The version
+// is read again at most once every versionCheckInterval, so datasets
+// activated by other processes are picked up after that long.
+func (d *RedisStore) activeVersion(ctx context.Context) (int64, error) {
+	d.mu.Lock()
+	version, fresh := d.active, d.active != 0 && time.Now().Before(d.activeCheck)
+	d.mu.Unlock()
+
+	if fresh {
+		return version, nil
+	}
+
+	return d.loadActiveVersion(ctx)
+}
+
+// loadActiveVersion reads the version of the dataset currently being served
+// and remembers it for activeVersion.
+func (d *RedisStore) loadActiveVersion(ctx context.Context) (int64, error) {
+	version, err := d.conn(ctx).Get(d.key(activeVersionKey)).Int64()
+	if err == redis.Nil {
+		return 0, ErrNotReady
+	} else if err != nil {
+		return 0, err
+	}
+
+	d.setActiveVersion(version)
+
+	return version, nil
+}
+
+func (d *RedisStore) setActiveVersion(version int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.active = version
+	d.activeCheck = time.Now().Add(versionCheckInterval)
+}
+
+// stagingVersion returns the version new entries are being loaded into,
+// allocating a new one if this is the first write.
+func (d *RedisStore) stagingVersion(ctx context.Context) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.staging == 0 {
+		version, err := d.conn(ctx).Incr(d.key(nextVersionKey)).Result()
+		if err != nil {
+			return 0, err
+		}
+
+		if err := d.conn(ctx).SAdd(d.key(loadingVersionsKey), version).Err(); err != nil {
+			return 0, err
+		}
+
+		log.Printf("Loading dataset version %d", version)
+		d.staging = version
+	}
+
+	return d.staging, nil
+}
+
+// GetSynonyms returns all synonyms of word for the given lexeme ordered by
+// descending weight.
+func (d *RedisStore) GetSynonyms(ctx context.Context, word string, lexeme Lexeme) ([]string, error) {
+	version, err := d.activeVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.conn(ctx).ZRevRange(d.key(versionedKey(version, synonymKey(word, lexeme))), 0, -1).Result()
+}
+
+// GetSynonymSets returns the weighted synonyms of every lexeme for each word
+// from the active dataset in a single pipeline.
+func (d *RedisStore) GetSynonymSets(ctx context.Context, words []string) (map[string]SynonymSets, error) {
+	return d.GetRelatedSets(ctx, words, Synonymous)
+}
+
+// GetRelatedSets returns the weighted related words of every lexeme for each
+// word from the active dataset in a single pipeline.
+func (d *RedisStore) GetRelatedSets(ctx context.Context, words []string, relation Relation) (map[string]SynonymSets, error) {
+	version, err := d.activeVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		unique  = uniqueWords(words)
+		results = make([][]*redis.ZSliceCmd, len(unique))
+	)
+
+	_, err = d.conn(ctx).Pipelined(func(pipe redis.Pipeliner) error {
+		for i, word := range unique {
+			results[i] = make([]*redis.ZSliceCmd, len(ordering))
+
+			for idx, l := range ordering {
+				results[i][idx] = pipe.ZRevRangeWithScores(d.key(versionedKey(version, relationKey(word, l, relation))), 0, -1)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make(map[string]SynonymSets, len(unique))
+	for i, word := range unique {
+		sets[word] = make(SynonymSets)
+
+		for idx, l := range ordering {
+			members := results[i][idx].Val()
+			if len(members) == 0 {
+				continue
+			}
+
+			synonyms := make([]Synonym, len(members))
+			for j, z := range members {
+				synonyms[j] = Synonym{Word: z.Member.(string), Weight: z.Score}
+			}
+
+			sets[word][l] = synonyms
+		}
+	}
+
+	return sets, nil
+}
+
+// AddSynonyms adds all entries to the dataset version being loaded in a
+// single pipeline. Synonyms are stored in sorted sets scored by their weight.
+// The pipeline is not transactional since entries span many hash slots when
+// running against a cluster.
+func (d *RedisStore) AddSynonyms(ctx context.Context, entries []Entry) error {
+	version, err := d.stagingVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.conn(ctx).Pipelined(func(pipe redis.Pipeliner) error {
+		keys := make([]interface{}, len(entries))
+
+		for idx, e := range entries {
+			members := make([]*redis.Z, len(e.Synonyms))
+			for i, s := range e.Synonyms {
+				members[i] = &redis.Z{Score: s.Weight, Member: s.Word}
+			}
+
+			keys[idx] = d.key(versionedKey(version, relationKey(e.Word, e.Lexeme, e.Relation)))
+			pipe.ZAdd(keys[idx].(string), members...)
+		}
+
+		if len(keys) > 0 {
+			pipe.SAdd(d.key(versionedKey(version, datasetKeysKey)), keys...)
+		}
+
+		return nil
+	})
+
+	return err
+}
+
+const (
+	statusChannelName = "status"
+	readyMessage      = "ready"
+)
+
+// SendReady records the dataset that was just loaded and atomically makes it
+// the active dataset. Then it sends the ready message on the status channel
+// for any bots already waiting. Datasets without any entries are never
+// activated.
+func (d *RedisStore) SendReady(ctx context.Context, words int) (DatasetInfo, error) {
+	d.mu.Lock()
+	version := d.staging
+	d.mu.Unlock()
+
+	if version == 0 {
+		return DatasetInfo{}, ErrEmptyDataset
+	}
+
+	info := DatasetInfo{Version: version, Words: words, LoadedAt: time.Now().UTC()}
+
+	record, err := json.Marshal(info)
+	if err != nil {
+		return DatasetInfo{}, err
+	}
+
+	_, err = d.conn(ctx).TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(d.key(versionsKey), strconv.FormatInt(version, 10), record)
+		pipe.Set(d.key(activeVersionKey), version, 0)
+		pipe.SRem(d.key(loadingVersionsKey), version)
+
+		return nil
+	})
+	if err != nil {
+		return DatasetInfo{}, err
+	}
+
+	d.mu.Lock()
+	d.staging = 0
+	d.mu.Unlock()
+
+	d.setActiveVersion(version)
+
+	return info, d.conn(ctx).Publish(d.key(statusChannelName), readyMessage).Err()
+}
+
+// GetDatasetInfo returns the persisted readiness record of the active
+// dataset. The active version is always read from Redis.
+func (d *RedisStore) GetDatasetInfo(ctx context.Context) (DatasetInfo, error) {
+	version, err := d.loadActiveVersion(ctx)
+	if err != nil {
+		return DatasetInfo{}, err
+	}
+
+	return d.datasetInfo(ctx, version)
+}
+
+func (d *RedisStore) datasetInfo(ctx context.Context, version int64) (DatasetInfo, error) {
+	record, err := d.conn(ctx).HGet(d.key(versionsKey), strconv.FormatInt(version, 10)).Bytes()
+	if err == redis.Nil {
+		return DatasetInfo{}, ErrUnknownDataset
+	} else if err != nil {
+		return DatasetInfo{}, err
+	}
+
+	var info DatasetInfo
+	return info, json.Unmarshal(record, &info)
+}
+
+// WaitForReady checks for a persisted readiness record and, if there isn't
+// one yet, waits for `ready` status message in `status` pubsub channel.
+func (d *RedisStore) WaitForReady(ctx context.Context, timeout int) error {
+	if timeout == 0 {
+		log.Println("Skipping database check...")
+		return nil
+	}
+
+	// Subscribe before checking the readiness record so that a ready message
+	// sent in between can't be missed.
+	pubsub := d.conn(ctx).Subscribe(d.key(statusChannelName))
+	defer pubsub.Close()
+
+	if _, err := pubsub.Receive(); err != nil {
+		return err
+	}
+
+	info, err := d.GetDatasetInfo(ctx)
+	if err == nil {
+		log.Printf("Found dataset %s", info)
+		return nil
+	} else if err != ErrNotReady {
+		return err
+	}
+
+	log.Printf("Waiting for ready status on channel '%s' for %ds", d.key(statusChannelName), timeout)
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case msg := <-ch:
+			if msg.Payload == readyMessage {
+				return nil
+			} else {
+				return fmt.Errorf("got unexpected status message '%s' on ready channel", msg.Payload)
+			}
+		case <-time.After(time.Duration(timeout) * time.Second):
+			return fmt.Errorf("Channel '%s timed out after %ds", d.key(statusChannelName), timeout)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close closes the connection to the datastore.
+func (d *RedisStore) Close() error {
+	return d.client.Close()
+}
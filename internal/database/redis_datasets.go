@@ -0,0 +1,239 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/go-redis/redis/v7"
+)
+
+const scanBatchSize = 1000
+
+// ListDatasets returns every fully loaded dataset ordered by version.
+func (d *RedisStore) ListDatasets(ctx context.Context) ([]DatasetInfo, error) {
+	records, err := d.conn(ctx).HGetAll(d.key(versionsKey)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	datasets := make([]DatasetInfo, 0, len(records))
+	for _, record := range records {
+		var info DatasetInfo
+		if err := json.Unmarshal([]byte(record), &info); err != nil {
+			return nil, err
+		}
+
+		datasets = append(datasets, info)
+	}
+
+	sort.Slice(datasets, func(i, j int) bool {
+		return datasets[i].Version < datasets[j].Version
+	})
+
+	return datasets, nil
+}
+
+// ActivateDataset atomically switches lookups over to the given dataset
+// version and notifies any bots waiting on the status channel.
+func (d *RedisStore) ActivateDataset(ctx context.Context, version int64) error {
+	if _, err := d.datasetInfo(ctx, version); err != nil {
+		return err
+	}
+
+	if err := d.conn(ctx).Set(d.key(activeVersionKey), version, 0).Err(); err != nil {
+		return err
+	}
+
+	d.setActiveVersion(version)
+
+	return d.conn(ctx).Publish(d.key(statusChannelName), readyMessage).Err()
+}
+
+// PruneDatasets deletes all inactive datasets except for the keep most recent
+// ones. This includes partially loaded datasets left behind by failed loads.
+// Only versions recorded as loaded or being loaded are considered.
+func (d *RedisStore) PruneDatasets(ctx context.Context, keep int) ([]int64, error) {
+	datasets, err := d.ListDatasets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := d.loadActiveVersion(ctx)
+	if err != nil && err != ErrNotReady {
+		return nil, err
+	}
+
+	loading, err := d.conn(ctx).SMembers(d.key(loadingVersionsKey)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	retained := map[int64]bool{active: true}
+	for i := len(datasets) - 1; i >= 0 && keep > 0; i-- {
+		if v := datasets[i].Version; v != active {
+			retained[v] = true
+			keep--
+		}
+	}
+
+	var versions []int64
+	for _, info := range datasets {
+		if !retained[info.Version] {
+			versions = append(versions, info.Version)
+		}
+	}
+
+	// Never delete a dataset that is still being loaded. Versions are
+	// allocated in order so anything newer than the last completed dataset
+	// might be in progress.
+	var newest int64
+	if len(datasets) > 0 {
+		newest = datasets[len(datasets)-1].Version
+	}
+
+	for _, member := range loading {
+		if v, err := strconv.ParseInt(member, 10, 64); err == nil && v < newest {
+			versions = append(versions, v)
+		}
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+
+	for _, v := range versions {
+		if err := d.deleteDataset(ctx, v); err != nil {
+			return nil, err
+		}
+	}
+
+	log.Printf("Pruned %d dataset versions", len(versions))
+
+	return versions, nil
+}
+
+// deleteDataset deletes every key of a dataset version listed in its set of
+// keys, followed by the records of the version itself.
+func (d *RedisStore) deleteDataset(ctx context.Context, version int64) error {
+	var (
+		index  = d.key(versionedKey(version, datasetKeysKey))
+		cursor uint64
+	)
+
+	for {
+		keys, next, err := d.conn(ctx).SScan(index, cursor, "", scanBatchSize).Result()
+		if err != nil {
+			return err
+		}
+
+		// Keys are deleted one by one since they span many hash slots when
+		// running against a cluster.
+		_, err = d.conn(ctx).Pipelined(func(pipe redis.Pipeliner) error {
+			for _, key := range keys {
+				pipe.Del(key)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+
+	field := strconv.FormatInt(version, 10)
+
+	_, err := d.conn(ctx).Pipelined(func(pipe redis.Pipeliner) error {
+		pipe.Del(index)
+		pipe.HDel(d.key(versionsKey), field)
+		pipe.SRem(d.key(loadingVersionsKey), field)
+
+		return nil
+	})
+
+	return err
+}
+
+// PruneLegacyKeys deletes the synonym keys left behind by releases that
+// wrote a single dataset straight into the namespace. Every noun, verb,
+// adjective and adverb key within the namespace is deleted, since their
+// names are all that identifies them.
+func (d *RedisStore) PruneLegacyKeys(ctx context.Context) (int64, error) {
+	var count int64
+
+	for _, lexeme := range ordering {
+		err := d.scanKeys(ctx, escapePattern(d.key(lexeme.String()))+":*", func(pipe redis.Pipeliner, key string) {
+			pipe.Del(key)
+			atomic.AddInt64(&count, 1)
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	log.Printf("Pruned %d unversioned keys", count)
+
+	return count, nil
+}
+
+// escapePattern escapes all glob characters in s so that it only matches
+// itself when used in a SCAN pattern.
+func escapePattern(s string) string {
+	var b strings.Builder
+
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// scanKeys calls fn for every key matching pattern. Commands added to the
+// pipeline by fn are executed after each batch of keys. When running against
+// a cluster every master node is scanned concurrently.
+func (d *RedisStore) scanKeys(ctx context.Context, pattern string, fn func(pipe redis.Pipeliner, key string)) error {
+	scan := func(client redis.Cmdable) error {
+		var cursor uint64
+
+		for {
+			keys, next, err := client.Scan(cursor, pattern, scanBatchSize).Result()
+			if err != nil {
+				return err
+			}
+
+			pipe := d.conn(ctx).Pipeline()
+			for _, key := range keys {
+				fn(pipe, key)
+			}
+
+			if _, err := pipe.Exec(); err != nil && err != redis.Nil {
+				return err
+			}
+
+			if cursor = next; cursor == 0 {
+				return nil
+			}
+		}
+	}
+
+	if cluster, ok := d.conn(ctx).(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(func(client *redis.Client) error {
+			return scan(client)
+		})
+	}
+
+	return scan(d.conn(ctx))
+}
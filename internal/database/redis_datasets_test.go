@@ -0,0 +1,9 @@
+package database
+
+import "testing"
+
+func TestEscapePattern(t *testing.T) {
+	if p := escapePattern(`en*[1]?\`); p != `en\*\[1\]\?\\` {
+		t.Errorf("Expected %s\n Got %s", `en\*\[1\]\?\\`, p)
+	}
+}
@@ -0,0 +1,15 @@
+package database
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRedisSendReadyEmpty(t *testing.T) {
+	// Nothing was staged, so Redis is never contacted.
+	store := &RedisStore{}
+
+	if _, err := store.SendReady(context.Background(), 0); err != ErrEmptyDataset {
+		t.Errorf("Expected %v\n Got %v", ErrEmptyDataset, err)
+	}
+}
@@ -0,0 +1,67 @@
+package database
+
+import "fmt"
+
+// Relation is how a word listed in the thesaurus relates to the head word it
+// is listed under. The thesaurus marks every relation other than plain
+// synonyms with a qualifier such as "(antonym)".
+type Relation int
+
+const (
+	// Synonymous words mean the same as the head word.
+	Synonymous Relation = iota
+	// Antonym words mean the opposite of the head word.
+	Antonym
+	// SimilarTerm words mean nearly the same as the head word.
+	SimilarTerm
+	// GenericTerm words are more general than the head word.
+	GenericTerm
+	// SpecificTerm words are more specific than the head word.
+	SpecificTerm
+	// RelatedTerm words are otherwise related to the head word.
+	RelatedTerm
+)
+
+var relationStringMap = map[Relation]string{
+	Synonymous:   "synonym",
+	Antonym:      "antonym",
+	SimilarTerm:  "similar term",
+	GenericTerm:  "generic term",
+	SpecificTerm: "specific term",
+	RelatedTerm:  "related term",
+}
+
+// Short names of relations used in keys.
+var relationKeyMap = map[Relation]string{
+	Antonym:      "ant",
+	SimilarTerm:  "sim",
+	GenericTerm:  "gen",
+	SpecificTerm: "spec",
+	RelatedTerm:  "rel",
+}
+
+func (r Relation) String() string {
+	return relationStringMap[r]
+}
+
+// ParseRelation converts the qualifier of a word in the thesaurus data file,
+// without its parentheses, into a Relation.
+func ParseRelation(qualifier string) (Relation, error) {
+	for r, name := range relationStringMap {
+		if r != Synonymous && name == qualifier {
+			return r, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown relation qualifier '%s'", qualifier)
+}
+
+// relationKey returns the key of the words related to word. Synonyms use the
+// plain key of the word and lexeme, every other relation its own key family.
+func relationKey(word string, lexeme Lexeme, relation Relation) string {
+	if relation == Synonymous {
+		return synonymKey(word, lexeme)
+	}
+
+	return fmt.Sprintf("%s:%s:%s", lexeme, relationKeyMap[relation], word)
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Synonym is a single synonym along with a weight describing how fitting or
+// common it is. Higher weights are better matches.
+type Synonym struct {
+	Word   string
+	Weight float64
+}
+
+// DefaultWeight is the weight given to synonyms without any other ranking.
+const DefaultWeight = 1.0
+
+// Weights are never allowed to drop below this so that every synonym can
+// still be picked at the absurd end of the scale.
+const minWeight = 1e-6
+
+// Absurdity controls how synonyms are sampled by weight. At SubtlyOff the
+// most fitting synonyms are strongly preferred, Balanced ignores the weights
+// entirely and MaximallyAbsurd strongly prefers the most obscure synonyms.
+// Values in between are interpolated.
+type Absurdity float64
+
+const (
+	// SubtlyOff picks the most fitting synonyms almost every time.
+	SubtlyOff Absurdity = 0
+	// Balanced picks every synonym with the same probability.
+	Balanced Absurdity = 0.5
+	// MaximallyAbsurd picks the most obscure synonyms almost every time.
+	MaximallyAbsurd Absurdity = 1
+)
+
+// How strongly the ends of the absurdity scale favour their synonyms. Each
+// weight is raised to a power between absurdityBias and -absurdityBias.
+const absurdityBias = 3
+
+func (a Absurdity) exponent() float64 {
+	switch {
+	case a < SubtlyOff:
+		a = SubtlyOff
+	case a > MaximallyAbsurd:
+		a = MaximallyAbsurd
+	}
+
+	return absurdityBias * float64(1-2*a)
+}
+
+// pick samples one synonym with a probability depending on its weight and the
+// absurdity. synonyms must not be empty.
+func (a Absurdity) pick(synonyms []Synonym) string {
+	var (
+		exp     = a.exponent()
+		weights = make([]float64, len(synonyms))
+		total   float64
+	)
+
+	for i, s := range synonyms {
+		weights[i] = math.Pow(math.Max(s.Weight, minWeight), exp)
+		total += weights[i]
+	}
+
+	target := rand.Float64() * total
+	for i, w := range weights {
+		if target -= w; target < 0 {
+			return synonyms[i].Word
+		}
+	}
+
+	return synonyms[len(synonyms)-1].Word
+}
+
+// Sample picks one of synonyms with a probability depending on its weight
+// and the absurdity, like SynonymSets.Pick. It returns false if there are no
+// synonyms to pick from.
+func (a Absurdity) Sample(synonyms []Synonym) (string, bool) {
+	if len(synonyms) == 0 {
+		return "", false
+	}
+
+	return a.pick(synonyms), true
+}
+
+// mergeSynonyms adds synonyms to existing, replacing the weight of any
+// synonym that is already present. The result is sorted by descending weight.
+func mergeSynonyms(existing, synonyms []Synonym) []Synonym {
+	index := make(map[string]int, len(existing)+len(synonyms))
+	merged := make([]Synonym, 0, len(existing)+len(synonyms))
+
+	for _, group := range [][]Synonym{existing, synonyms} {
+		for _, s := range group {
+			if i, ok := index[s.Word]; ok {
+				merged[i].Weight = s.Weight
+				continue
+			}
+
+			index[s.Word] = len(merged)
+			merged = append(merged, s)
+		}
+	}
+
+	sortSynonyms(merged)
+
+	return merged
+}
+
+// sortSynonyms orders synonyms by descending weight. Synonyms of equal weight
+// keep their relative order.
+func sortSynonyms(synonyms []Synonym) {
+	sort.SliceStable(synonyms, func(i, j int) bool {
+		return synonyms[i].Weight > synonyms[j].Weight
+	})
+}
+
+// synonymWords returns just the words of synonyms.
+func synonymWords(synonyms []Synonym) []string {
+	words := make([]string, len(synonyms))
+	for i, s := range synonyms {
+		words[i] = s.Word
+	}
+
+	return words
+}
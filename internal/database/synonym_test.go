@@ -0,0 +1,51 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAbsurdityPick(t *testing.T) {
+	synonyms := []Synonym{{"common", 1}, {"obscure", 0.001}}
+
+	tests := []struct {
+		absurdity Absurdity
+		expected  string
+	}{
+		{SubtlyOff, "common"},
+		{MaximallyAbsurd, "obscure"},
+	}
+
+	for _, test := range tests {
+		for i := 0; i < 100; i++ {
+			if w := test.absurdity.pick(synonyms); w != test.expected {
+				t.Fatalf("Expected %s at absurdity %v\n Got %s", test.expected, test.absurdity, w)
+			}
+		}
+	}
+}
+
+func TestAbsurdityBalanced(t *testing.T) {
+	synonyms := []Synonym{{"common", 1}, {"obscure", 0.001}}
+
+	picked := make(map[string]int)
+	for i := 0; i < 1000; i++ {
+		picked[Balanced.pick(synonyms)]++
+	}
+
+	if picked["common"] < 400 || picked["obscure"] < 400 {
+		t.Errorf("Expected both synonyms to be picked equally often\n Got %+v", picked)
+	}
+}
+
+func TestMergeSynonyms(t *testing.T) {
+	merged := mergeSynonyms(
+		[]Synonym{{"a", 1}, {"b", 0.5}},
+		[]Synonym{{"c", 0.75}, {"b", 2}},
+	)
+
+	if expected := []Synonym{{"b", 2}, {"a", 1}, {"c", 0.75}}; !cmp.Equal(merged, expected) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected, merged)
+	}
+}
@@ -0,0 +1,266 @@
+package database
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+)
+
+const (
+	defaultRedisPort    = "6379"
+	defaultSentinelPort = "26379"
+	// Used by go-redis when no dial timeout is configured.
+	defaultDialTimeout = 5 * time.Second
+)
+
+type redisMode int
+
+const (
+	redisSingle redisMode = iota
+	redisSentinel
+	redisCluster
+)
+
+// redisConfig holds everything needed to construct a client for any of the
+// supported Redis deployment modes.
+type redisConfig struct {
+	mode             redisMode
+	opts             *redis.UniversalOptions
+	sentinelPassword string
+}
+
+// newClient creates a client for the configured deployment mode.
+func (c redisConfig) newClient() redis.UniversalClient {
+	switch c.mode {
+	case redisSentinel:
+		opts := c.opts.Failover()
+		opts.SentinelPassword = c.sentinelPassword
+
+		return redis.NewFailoverClient(opts)
+	case redisCluster:
+		return redis.NewClusterClient(c.opts.Cluster())
+	default:
+		return redis.NewClient(c.opts.Simple())
+	}
+}
+
+// parseRedisURI parses a datastore URI into Redis client options. URIs are
+// formatted like
+//
+//	redis[s]://[[username]:password@]host[:port][/db][?option=value&...]
+//	redis[s]-sentinel://[[username]:password@]host[:port][,host[:port]...]/master[/db][?option=value&...]
+//	redis[s]-cluster://[[username]:password@]host[:port][,host[:port]...][?option=value&...]
+//
+// The rediss schemes enable TLS. Supported options are dial_timeout,
+// read_timeout, write_timeout (durations such as 5s or a number of seconds),
+// pool_size and, for sentinel URIs, sentinel_password. For compatibility a
+// bare host:port is also accepted.
+func parseRedisURI(uri string) (redisConfig, error) {
+	if !strings.Contains(uri, "://") {
+		uri = "redis://" + uri
+	}
+
+	uri, hosts := splitHosts(uri)
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		// The parse error quotes the whole URI, credentials included.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return redisConfig{}, err
+	}
+
+	var (
+		config = redisConfig{opts: &redis.UniversalOptions{}}
+		port   = defaultRedisPort
+		scheme = u.Scheme
+	)
+
+	switch {
+	case strings.HasSuffix(scheme, "-sentinel"):
+		config.mode = redisSentinel
+		port = defaultSentinelPort
+		scheme = strings.TrimSuffix(scheme, "-sentinel")
+	case strings.HasSuffix(scheme, "-cluster"):
+		config.mode = redisCluster
+		scheme = strings.TrimSuffix(scheme, "-cluster")
+	}
+
+	if scheme != "redis" && scheme != "rediss" {
+		return redisConfig{}, fmt.Errorf("invalid redis URI scheme '%s'", u.Scheme)
+	}
+
+	if u.User != nil {
+		config.opts.Username = u.User.Username()
+		config.opts.Password, _ = u.User.Password()
+	}
+
+	for _, host := range hosts {
+		addr, err := parseAddr(host, port)
+		if err != nil {
+			return redisConfig{}, err
+		}
+
+		config.opts.Addrs = append(config.opts.Addrs, addr)
+	}
+
+	if len(config.opts.Addrs) > 1 && config.mode == redisSingle {
+		return redisConfig{}, errors.New("multiple addresses require a sentinel or cluster URI")
+	}
+
+	var path []string
+	if p := strings.Trim(u.Path, "/"); p != "" {
+		path = strings.Split(p, "/")
+	}
+
+	if config.mode == redisSentinel {
+		if len(path) == 0 {
+			return redisConfig{}, errors.New("sentinel URI is missing the master name")
+		}
+
+		config.opts.MasterName, path = path[0], path[1:]
+	}
+
+	switch {
+	case len(path) > 1:
+		return redisConfig{}, fmt.Errorf("invalid redis URI path '%s'", u.Path)
+	case len(path) == 1 && config.mode == redisCluster:
+		return redisConfig{}, errors.New("redis cluster only supports database 0")
+	case len(path) == 1:
+		if config.opts.DB, err = strconv.Atoi(path[0]); err != nil {
+			return redisConfig{}, fmt.Errorf("invalid redis database number '%s'", path[0])
+		}
+	}
+
+	if scheme == "rediss" {
+		config.opts.TLSConfig = &tls.Config{}
+		config.opts.Dialer = dialTLS(config.opts)
+	}
+
+	for key, values := range u.Query() {
+		value := values[len(values)-1]
+
+		switch {
+		case key == "dial_timeout":
+			config.opts.DialTimeout, err = parseTimeout(value)
+		case key == "read_timeout":
+			config.opts.ReadTimeout, err = parseTimeout(value)
+		case key == "write_timeout":
+			config.opts.WriteTimeout, err = parseTimeout(value)
+		case key == "pool_size":
+			config.opts.PoolSize, err = strconv.Atoi(value)
+		case key == "sentinel_password" && config.mode == redisSentinel:
+			config.sentinelPassword = value
+		default:
+			return redisConfig{}, fmt.Errorf("unknown redis URI option '%s'", key)
+		}
+
+		// Values are left out of the error since options such as
+		// sentinel_password are secret.
+		if err != nil {
+			return redisConfig{}, fmt.Errorf("invalid value for redis URI option '%s'", key)
+		}
+	}
+
+	return config, nil
+}
+
+// splitHosts replaces the comma separated list of hosts in uri with a single
+// placeholder, since url.Parse rejects lists containing IPv6 addresses. It
+// returns the resulting URI and the hosts.
+func splitHosts(uri string) (string, []string) {
+	i := strings.Index(uri, "://") + len("://")
+
+	end := strings.IndexAny(uri[i:], "/?#")
+	if end < 0 {
+		end = len(uri)
+	} else {
+		end += i
+	}
+
+	if at := strings.LastIndex(uri[i:end], "@"); at >= 0 {
+		i += at + 1
+	}
+
+	return uri[:i] + "localhost" + uri[end:], strings.Split(uri[i:end], ",")
+}
+
+// parseAddr turns a host with an optional port into an address that can be
+// dialed, using port when none is given.
+func parseAddr(host, port string) (string, error) {
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host = h
+		if p != "" {
+			port = p
+		}
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	} else if strings.Contains(host, ":") {
+		return "", fmt.Errorf("invalid redis address '%s'", host)
+	}
+
+	if n, err := strconv.ParseUint(port, 10, 16); err != nil || n == 0 {
+		return "", fmt.Errorf("invalid redis port '%s'", port)
+	}
+
+	if host == "" {
+		host = "localhost"
+	}
+
+	return net.JoinHostPort(host, port), nil
+}
+
+// dialTLS returns a dialer verifying the certificate of every server against
+// the address actually dialed. go-redis shares a single TLS config between
+// the sentinel, master and cluster node connections, so a fixed server name
+// would only fit one of them.
+func dialTLS(opts *redis.UniversalOptions) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		timeout := opts.DialTimeout
+		if timeout == 0 {
+			timeout = defaultDialTimeout
+		}
+
+		dialer := &tls.Dialer{
+			NetDialer: &net.Dialer{Timeout: timeout, KeepAlive: 5 * time.Minute},
+			Config:    tlsConfigFor(opts.TLSConfig, addr),
+		}
+
+		return dialer.DialContext(ctx, network, addr)
+	}
+}
+
+// tlsConfigFor returns a copy of config with the server name set to the host
+// of addr.
+func tlsConfigFor(config *tls.Config, addr string) *tls.Config {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+
+	config = config.Clone()
+	config.ServerName = host
+
+	return config
+}
+
+// parseTimeout parses either a Go duration string or a plain number of
+// seconds.
+func parseTimeout(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+
+	return time.ParseDuration(value)
+}
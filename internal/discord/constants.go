@@ -1,26 +1,102 @@
 package discord
 
-import "github.com/bwmarrin/discordgo"
+import (
+	"github.com/MrFlynn/thesaurize/internal/database"
+	"github.com/bwmarrin/discordgo"
+)
 
 // Structs for embedding help and bot info.
 var (
-	command = &discordgo.ApplicationCommand{
-		Name:        "thesaurize",
-		Description: "Run some words through a thesaurus",
-		Options: []*discordgo.ApplicationCommandOption{
-			{
-				Type:        discordgo.ApplicationCommandOptionString,
-				Name:        "words",
-				Description: "Words to run through thesaurus",
+	// Options shared by every command.
+	commandOptions = []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "words",
+			Description: "Words to run through thesaurus",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionUser,
+			Name:        "member",
+			Description: "Thesaurus this member's last message",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionInteger,
+			Name:        "absurdity",
+			Description: "How obscure the chosen synonyms should be",
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "Subtly off", Value: 0},
+				{Name: "A little odd", Value: 25},
+				{Name: "Anything goes", Value: 50},
+				{Name: "Bizarre", Value: 75},
+				{Name: "Maximally absurd", Value: 100},
 			},
-			{
-				Type:        discordgo.ApplicationCommandOptionUser,
-				Name:        "member",
-				Description: "Thesaurus this member's last message",
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "style",
+			Description: "How replacement words are chosen",
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "Random", Value: "random"},
+				{Name: "Longest", Value: "longest"},
+				{Name: "Shortest", Value: "shortest"},
+				{Name: "Most syllables", Value: "syllables"},
+				{Name: "Alliterative", Value: "alliterative"},
+				{Name: "Rhyming", Value: "rhyming"},
 			},
 		},
 	}
 
+	commands = []*discordgo.ApplicationCommand{
+		{
+			Name:        "thesaurize",
+			Description: "Run some words through a thesaurus",
+			Options: append(commandOptions,
+				&discordgo.ApplicationCommandOption{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Replace words with synonyms, vaguer, more specific, similar or related words",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Synonyms", Value: "synonyms"},
+						{Name: "Vaguify", Value: "vaguify"},
+						{Name: "Specify", Value: "specify"},
+						{Name: "Similar terms", Value: "similar"},
+						{Name: "Related terms", Value: "related"},
+					},
+				},
+				&discordgo.ApplicationCommandOption{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "depth",
+					Description: "How many steps vaguer or more specific to go",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "1", Value: 1},
+						{Name: "2", Value: 2},
+						{Name: "3", Value: 3},
+					},
+				},
+			),
+		},
+		{
+			Name:        "antonymize",
+			Description: "Say the opposite by replacing words with their antonyms",
+			Options:     commandOptions,
+		},
+	}
+
+	// Relation of the words each command replaces words with.
+	commandRelations = map[string]database.Relation{
+		"thesaurize": database.Synonymous,
+		"antonymize": database.Antonym,
+	}
+
+	// Relation of the words each mode of /thesaurize replaces words with.
+	modeRelations = map[string]database.Relation{
+		"synonyms": database.Synonymous,
+		"vaguify":  database.GenericTerm,
+		"specify":  database.SpecificTerm,
+		"similar":  database.SimilarTerm,
+		"related":  database.RelatedTerm,
+	}
+
 	helpEmbed = &discordgo.MessageEmbed{
 		Title: ":book: Thesaurize Bot for Discord :book:",
 		URL:   "https://github.com/MrFlynn/thesaurize",
@@ -43,6 +119,18 @@ var (
 				Name:  "Thesaurizing a Previous Message",
 				Value: "Use the command `/thesaurize member:@member` to thesaurize their last message.",
 			},
+			{
+				Name:  "Saying the Opposite",
+				Value: "Use `/antonymize` in place of `/thesaurize` to replace words with their antonyms instead.",
+			},
+			{
+				Name:  "Vaguer or More Specific",
+				Value: "Set `mode` to vaguify or specify to replace words with vaguer or more specific ones, and `depth` to go further. Similar and related terms are modes too.",
+			},
+			{
+				Name:  "Tuning the Output",
+				Value: "Add the `absurdity` option to pick anything from subtly off to maximally absurd synonyms, and the `style` option to prefer long, short, alliterative or rhyming ones.",
+			},
 		},
 	}
 )
@@ -1,12 +1,14 @@
 package discord
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
-	"os/signal"
+	"time"
 
 	"github.com/MrFlynn/thesaurize/internal/database"
+	"github.com/MrFlynn/thesaurize/internal/loader"
 	"github.com/bwmarrin/discordgo"
 	"github.com/urfave/cli/v2"
 )
@@ -16,11 +18,20 @@ var (
 	skipCommonWords bool
 )
 
+// How often statistics of the synonym cache are logged.
+const cacheStatsInterval = 15 * time.Minute
+
 // bot type provides methods for communicating with discord.
 type bot struct {
+	// ctx is cancelled when the bot is shutting down. Every interaction
+	// derives its own deadline from it.
+	ctx            context.Context
 	key            string
-	database       database.Database
+	database       database.SynonymStore
 	serviceHandler *discordgo.Session
+
+	// Default absurdity used when a command doesn't specify one.
+	absurdity database.Absurdity
 }
 
 // Error handling for bot. Stores information on whether to expose error
@@ -48,17 +59,45 @@ func new(ctx *cli.Context) (bot, error) {
 		return bot{}, err
 	}
 
+	store, err := database.New(ctx.Context, ctx.String("datastore"), database.Options{
+		Timeout:   time.Duration(ctx.Int("timeout")) * time.Second,
+		KeyPrefix: ctx.String("key-prefix"),
+	})
+	if err != nil {
+		log.Println("Could not connect to datastore")
+		return bot{}, err
+	}
+
+	// Load the dataset into the datastore before starting if requested. The
+	// in-memory datastore starts out empty so it always needs a dataset.
+	if ctx.String("data") != "" {
+		if err := loader.Populate(ctx, store); err != nil {
+			log.Println("Could not load dataset into datastore")
+			return bot{}, err
+		}
+	} else if _, ok := store.(*database.MemoryStore); ok {
+		return bot{}, errors.New("an in-memory datastore requires a thesaurus data file")
+	}
+
+	if size := ctx.Int("cache-size"); size > 0 {
+		store = database.NewCachedStore(store, size, ctx.Duration("cache-ttl"))
+	}
+
 	return bot{
+		ctx:            ctx.Context,
 		key:            ctx.String("token"),
-		database:       database.New(ctx.String("datastore")),
+		database:       store,
 		serviceHandler: service,
+		absurdity:      database.Absurdity(ctx.Float64("absurdity")),
 	}, nil
 }
 
 func (b *bot) run(ctx *cli.Context) error {
 	var err error
 
-	err = b.database.WaitForReady(ctx.Int("timeout"))
+	defer b.database.Close()
+
+	err = b.database.WaitForReady(ctx.Context, ctx.Int("timeout"))
 	if err != nil {
 		log.Println(err)
 		return err
@@ -74,18 +113,41 @@ func (b *bot) run(ctx *cli.Context) error {
 
 	log.Print("Bot connected to discord")
 
-	_, err = b.serviceHandler.ApplicationCommandCreate(b.serviceHandler.State.User.ID, "", command)
-	if err != nil {
-		log.Println("Could not register application commands. Exiting...")
-		return err
+	for _, command := range commands {
+		_, err = b.serviceHandler.ApplicationCommandCreate(b.serviceHandler.State.User.ID, "", command)
+		if err != nil {
+			log.Println("Could not register application commands. Exiting...")
+			return err
+		}
 	}
 
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt, os.Kill)
+	// Periodically log cache statistics. Receiving from the nil channel
+	// blocks forever when no cache is configured.
+	var stats <-chan time.Time
+
+	cache, cached := b.database.(*database.CachedStore)
+	if cached {
+		ticker := time.NewTicker(cacheStatsInterval)
+		defer ticker.Stop()
+
+		stats = ticker.C
+	}
 
-	<-c
+	for running := true; running; {
+		select {
+		case <-stats:
+			log.Printf("Cache statistics: %s", cache.Stats())
+		case <-ctx.Done():
+			running = false
+		}
+	}
 
 	fmt.Printf("\n")
+
+	if cached {
+		log.Printf("Cache statistics: %s", cache.Stats())
+	}
+
 	log.Println("Bot shutting down. Goodbye...")
 
 	return err
@@ -1,16 +1,38 @@
 package discord
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
+	"time"
 
+	"github.com/MrFlynn/thesaurize/internal/database"
 	"github.com/MrFlynn/thesaurize/internal/transformer"
 	"github.com/bwmarrin/discordgo"
 )
 
 var commandFormat = regexp.MustCompile(`^</\w+:\d+>`)
 
+const (
+	// Discord only accepts the initial response to an interaction within
+	// this long of the interaction being created.
+	interactionResponseWindow = 3 * time.Second
+	// Time set aside for sending the response itself.
+	interactionResponseMargin = 500 * time.Millisecond
+)
+
+// interactionContext returns a context that is done once there is no longer
+// enough time left to respond to the interaction, or when the bot shuts down.
+func (b *bot) interactionContext(i *discordgo.Interaction) (context.Context, context.CancelFunc) {
+	created, err := discordgo.SnowflakeTimestamp(i.ID)
+	if now := time.Now(); err != nil || created.After(now) {
+		created = now
+	}
+
+	return context.WithDeadline(b.ctx, created.Add(interactionResponseWindow-interactionResponseMargin))
+}
+
 func errorHandler(s *discordgo.Session, i *discordgo.InteractionCreate, err error) {
 	msg := "Sorry. Something went wrong with the bot. Please try again later."
 	if botErr, ok := err.(botError); ok {
@@ -44,7 +66,10 @@ func errorHandler(s *discordgo.Session, i *discordgo.InteractionCreate, err erro
 }
 
 func (b *bot) commandHandler(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	if i.Data.Name == "thesaurize" {
+	if relation, ok := commandRelations[i.Data.Name]; ok {
+		ctx, cancel := b.interactionContext(i.Interaction)
+		defer cancel()
+
 		if len(i.Data.Options) < 1 {
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -58,16 +83,49 @@ func (b *bot) commandHandler(s *discordgo.Session, i *discordgo.InteractionCreat
 			return
 		}
 
-		switch i.Data.Options[0].Name {
-		case "words":
+		options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption, len(i.Data.Options))
+		for _, option := range i.Data.Options {
+			options[option.Name] = option
+		}
+
+		opts := transformer.Options{
+			SkipCommon: skipCommonWords,
+			Absurdity:  b.absurdity,
+			Relation:   relation,
+		}
+
+		if option, ok := options["absurdity"]; ok {
+			opts.Absurdity = database.Absurdity(option.IntValue()) / 100
+		}
+
+		if option, ok := options["style"]; ok {
+			strategy, err := transformer.ParseStrategy(option.StringValue())
+			if err != nil {
+				errorHandler(s, i, botError{why: err, t: errorUser})
+
+				return
+			}
+
+			opts.Strategy = strategy
+		}
+
+		if option, ok := options["mode"]; ok {
+			opts.Relation = modeRelations[option.StringValue()]
+		}
+
+		if option, ok := options["depth"]; ok {
+			opts.Depth = int(option.IntValue())
+		}
+
+		if option, ok := options["words"]; ok {
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
 				Data: &discordgo.InteractionApplicationCommandResponseData{
-					Content: transformer.Transform(i.Data.Options[0].StringValue(), b.database, skipCommonWords),
+					Content: transformer.Transform(ctx, option.StringValue(), b.database, opts),
 				},
 			})
-		case "member":
-			message, err := mentionParser(s, i.Data.Options[0].UserValue(s), i.ChannelID)
+		} else if option, ok := options["member"]; ok {
+			message, err := mentionParser(s, option.UserValue(s), i.ChannelID)
 			if err != nil {
 				errorHandler(s, i, err)
 
@@ -77,10 +135,10 @@ func (b *bot) commandHandler(s *discordgo.Session, i *discordgo.InteractionCreat
 			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 				Type: discordgo.InteractionResponseChannelMessageWithSource,
 				Data: &discordgo.InteractionApplicationCommandResponseData{
-					Content: transformer.Transform(message, b.database, skipCommonWords),
+					Content: transformer.Transform(ctx, message, b.database, opts),
 				},
 			})
-		default:
+		} else {
 			errorHandler(s, i, botError{
 				why: errors.New("Unknown option. Please provide some text or a username to the bot"),
 				t:   errorUser,
@@ -0,0 +1,46 @@
+package loader
+
+import "fmt"
+
+// ErrorKind classifies the stage of the load pipeline that failed.
+type ErrorKind int
+
+const (
+	// ConnectionError is returned when the datastore or the thesaurus
+	// source could not be reached.
+	ConnectionError ErrorKind = iota
+	// ParseError is returned when the thesaurus source could not be read.
+	ParseError
+	// FilterError is returned when the profanity filter could not be
+	// initialized.
+	FilterError
+	// WriteError is returned when data could not be written to the
+	// datastore.
+	WriteError
+)
+
+var errorKindStringMap = map[ErrorKind]string{
+	ConnectionError: "connection error",
+	ParseError:      "parse error",
+	FilterError:     "filter error",
+	WriteError:      "write error",
+}
+
+func (k ErrorKind) String() string {
+	return errorKindStringMap[k]
+}
+
+// Error is returned by the load pipeline. It wraps the underlying error along
+// with the stage of the pipeline that failed.
+type Error struct {
+	Kind ErrorKind
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
@@ -4,71 +4,63 @@ import (
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
-	"sync"
+	"time"
 
 	"github.com/MrFlynn/thesaurize/internal/database"
 	"github.com/urfave/cli/v2"
 )
 
-// Load loads data into a Redis database from a source thesaurus file.
+// Load loads data into the synonym store given by the `datastore` flag from a
+// source thesaurus file.
 func Load(ctx *cli.Context) error {
-	var (
-		uri = ctx.String("data")
+	store, err := database.New(ctx.Context, ctx.String("datastore"), database.Options{
+		Timeout:   time.Duration(ctx.Int("timeout")) * time.Second,
+		KeyPrefix: ctx.String("key-prefix"),
+	})
+	if err != nil {
+		return &Error{Kind: ConnectionError, Err: err}
+	}
 
-		rd  io.ReadCloser
-		err error
-	)
+	defer store.Close()
 
-	switch parts := strings.SplitN(uri, "://", 2); parts[0] {
-	case "file":
-		rd, err = os.Open(parts[1])
-	case "https", "http":
-		var resp *http.Response
-
-		resp, err = http.Get(uri)
-		if resp.StatusCode == http.StatusNotFound {
-			err = fmt.Errorf("unable to get file %s", uri)
+	if ctx.Bool("skip-if-loaded") {
+		if info, err := store.GetDatasetInfo(ctx.Context); err == nil {
+			log.Printf("Skipping load, found dataset %s", info)
+			return nil
 		}
-
-		rd = resp.Body
-	default:
-		err = fmt.Errorf("unknown protocol %s", parts[0])
 	}
 
+	return Populate(ctx, store)
+}
+
+// Populate loads data from the source thesaurus file given by the `data` flag
+// into an already opened synonym store. Loading stops early if the context is
+// cancelled, in which case the dataset is never marked as ready.
+func Populate(ctx *cli.Context, store database.SynonymStore) error {
+	rd, err := openSource(ctx.Context, ctx.String("data"))
 	if err != nil {
-		return err
+		return &Error{Kind: ConnectionError, Err: err}
 	}
 
 	defer rd.Close()
 
 	dataFile, err := getDataReaderFromZip(rd)
 	if err != nil {
-		return err
+		return &Error{Kind: ParseError, Err: err}
 	}
 
 	defer dataFile.Close()
 
-	var (
-		ch = make(chan entry)
-		wg sync.WaitGroup
-	)
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if err := pushToRedis(database.New(ctx.String("datastore")), ch, 500); err != nil {
-			log.Fatalf("Unable to push data to redis: %s", err)
-		}
-	}()
-
 	var filter *profanityFilter
 
 	if ctx.Bool("skip-profane-words") {
@@ -79,22 +71,76 @@ func Load(ctx *cli.Context) error {
 
 		filter = &profanityFilter{categories: categories}
 		if err := filter.init(ctx.String("profane-word-index-url")); err != nil {
-			log.Fatalf("Unable to initialize profanity filter: %s", err)
+			return &Error{Kind: FilterError, Err: err}
 		}
 	}
 
-	log.Println("Loading dataset into Redis backend")
+	var (
+		ch      = make(chan database.Entry)
+		pushErr = make(chan error, 1)
+	)
+
+	go func() {
+		pushErr <- pushToStore(ctx.Context, store, ch, 500)
+	}()
+
+	log.Println("Loading dataset into datastore")
+
+	words, scanErr := scanDataFile(ctx.Context, dataFile, ch, filter)
+
+	// Always wait for the writer to finish so that nothing is left running
+	// in the background once this function returns.
+	writeErr := <-pushErr
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if writeErr != nil {
+		return &Error{Kind: WriteError, Err: writeErr}
+	}
 
-	if err := scanDataFile(dataFile, ch, filter); err != nil {
-		log.Fatalf("Unable to read data file: %s", err)
+	if scanErr != nil {
+		return &Error{Kind: ParseError, Err: scanErr}
 	}
 
-	wg.Wait()
+	info, err := store.SendReady(ctx.Context, words)
+	if err != nil {
+		return &Error{Kind: WriteError, Err: err}
+	}
 
-	log.Println("Loading complete")
+	log.Printf("Loading complete, dataset %s", info)
 	return nil
 }
 
+// openSource opens the thesaurus archive at uri, which may either be a local
+// file or a file served over http(s).
+func openSource(ctx context.Context, uri string) (io.ReadCloser, error) {
+	switch parts := strings.SplitN(uri, "://", 2); parts[0] {
+	case "file":
+		return os.Open(parts[1])
+	case "https", "http":
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unable to get file %s, got status %s", uri, resp.Status)
+		}
+
+		return resp.Body, nil
+	default:
+		return nil, fmt.Errorf("unknown protocol %s", parts[0])
+	}
+}
+
 func getDataReaderFromZip(file io.Reader) (io.ReadCloser, error) {
 	buff := bytes.NewBuffer([]byte{})
 	sz, err := io.Copy(buff, file)
@@ -116,61 +162,128 @@ func getDataReaderFromZip(file io.Reader) (io.ReadCloser, error) {
 	return nil, errors.New("thesaurus data file not present in zip archive")
 }
 
-type entry struct {
-	key    string
-	values []string
-}
-
-func pushToRedis(db database.Database, in chan entry, queueSize int) error {
-	var (
-		count    int
-		pipeline = db.GetPipeline()
-	)
+// pushToStore writes entries from in to the store in batches of queueSize.
+// If a write fails the remaining entries are drained so that the producer
+// is never blocked.
+func pushToStore(ctx context.Context, store database.SynonymStore, in chan database.Entry, queueSize int) error {
+	batch := make([]database.Entry, 0, queueSize)
 
 	for e := range in {
-		if count >= queueSize {
-			if _, err := pipeline.Exec(); err != nil {
+		if len(batch) >= queueSize {
+			if err := store.AddSynonyms(ctx, batch); err != nil {
+				for range in {
+				}
+
 				return err
 			}
 
-			pipeline = db.GetPipeline()
-			count = 0
+			batch = batch[:0]
 		}
 
-		pipeline.SAdd(e.key, e.values)
-		count++
+		batch = append(batch, e)
 	}
 
-	if _, err := pipeline.Exec(); err != nil {
-		return err
+	if len(batch) > 0 {
+		return store.AddSynonyms(ctx, batch)
 	}
 
-	return db.SendReady()
+	return nil
 }
 
-func scanDataFile(rd io.Reader, out chan entry, filter *profanityFilter) error {
+// scanDataFile reads every word from the thesaurus data file and sends its
+// synonyms to out. It returns the number of words with at least one synonym.
+// Scanning stops once ctx is done.
+func scanDataFile(ctx context.Context, rd io.Reader, out chan database.Entry, filter *profanityFilter) (int, error) {
 	defer close(out)
 
+	var words int
+
 	scanner := bufio.NewScanner(rd)
 	if !scanner.Scan() {
-		return scanner.Err()
+		return words, scanner.Err()
 	}
 
 	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return words, err
+		}
+
 		word, synonyms, err := readSynonyms(scanner, filter)
 		if err != nil {
 			log.Printf("Unable to get synonyms for '%s': %s", word, err)
 		}
 
-		for lexeme, syns := range synonyms {
-			out <- entry{key: lexeme + ":" + word, values: syns}
+		var found bool
+
+		for sense, syns := range synonyms {
+			lexeme, err := database.ParseLexeme(sense.lexeme)
+			if err != nil {
+				log.Printf("Skipping synonyms for '%s': %s", word, err)
+				continue
+			}
+
+			out <- database.Entry{Word: word, Lexeme: lexeme, Relation: sense.relation, Synonyms: syns}
+			found = true
+
+			// Generic terms are the other end of specific terms, so they are
+			// linked back to the word to be able to walk in both directions.
+			if sense.relation == database.GenericTerm {
+				for _, syn := range syns {
+					out <- database.Entry{
+						Word:     syn.Word,
+						Lexeme:   lexeme,
+						Relation: database.SpecificTerm,
+						Synonyms: []database.Synonym{{Word: word, Weight: syn.Weight}},
+					}
+				}
+			}
+		}
+
+		if found {
+			words++
 		}
 	}
 
-	return scanner.Err()
+	return words, scanner.Err()
+}
+
+// senseWeight returns the weight of synonyms listed under the nth sense of a
+// word. MyThes lists the most common senses of a word first, so synonyms of
+// earlier senses are weighted higher.
+func senseWeight(n int) float64 {
+	return database.DefaultWeight / float64(n+1)
+}
+
+// relatedWords identifies the words with one relation to a head word under
+// one lexeme.
+type relatedWords struct {
+	lexeme   string
+	relation database.Relation
 }
 
-func readSynonyms(scanner *bufio.Scanner, filter *profanityFilter) (string, map[string][]string, error) {
+// Matches words with a qualifier such as "sick (antonym)".
+var qualifierRegex = regexp.MustCompile(`^(.+?)\s*\(([a-z ]+)\)$`)
+
+// parseQualifier splits the qualifier describing its relation off a word in
+// the data file. Words without a known qualifier are synonyms.
+func parseQualifier(field string) (string, database.Relation) {
+	match := qualifierRegex.FindStringSubmatch(field)
+	if match == nil {
+		return field, database.Synonymous
+	}
+
+	relation, err := database.ParseRelation(match[2])
+	if err != nil {
+		return field, database.Synonymous
+	}
+
+	return match[1], relation
+}
+
+// readSynonyms reads all senses of the next word in the data file, grouping
+// the listed words by lexeme and relation. Words are weighted by the position
+// of the first sense they appear in.
+func readSynonyms(scanner *bufio.Scanner, filter *profanityFilter) (string, map[relatedWords][]database.Synonym, error) {
 	wordHeader := strings.SplitN(scanner.Text(), "|", 2)
 	if fieldCount := len(wordHeader); fieldCount < 2 {
 		return "", nil, fmt.Errorf("invalid header, expected 2 fields, got %d", fieldCount)
@@ -190,7 +303,11 @@ func readSynonyms(scanner *bufio.Scanner, filter *profanityFilter) (string, map[
 		skip = filter.match(wordHeader[0])
 	}
 
-	synonyms := make(map[string][]string, rowCount)
+	var (
+		synonyms = make(map[relatedWords][]database.Synonym, rowCount)
+		senses   = make(map[string]int)
+		seen     = make(map[relatedWords]map[string]struct{})
+	)
 
 	for i := 0; i < rowCount && scanner.Scan(); i++ {
 		if skip {
@@ -203,16 +320,26 @@ func readSynonyms(scanner *bufio.Scanner, filter *profanityFilter) (string, map[
 		}
 
 		lexeme := strings.Trim(rowFields[0], "()")
-		for _, synonym := range rowFields[1:] {
+		weight := senseWeight(senses[lexeme])
+		senses[lexeme]++
+
+		for _, field := range rowFields[1:] {
+			synonym, relation := parseQualifier(field)
 			if filter != nil && filter.match(synonym) {
 				continue
 			}
 
-			if synWords, ok := synonyms[lexeme]; ok {
-				synonyms[lexeme] = append(synWords, synonym)
-			} else {
-				synonyms[lexeme] = []string{synonym}
+			key := relatedWords{lexeme: lexeme, relation: relation}
+			if seen[key] == nil {
+				seen[key] = make(map[string]struct{})
 			}
+
+			if _, ok := seen[key][synonym]; ok {
+				continue
+			}
+
+			seen[key][synonym] = struct{}{}
+			synonyms[key] = append(synonyms[key], database.Synonym{Word: synonym, Weight: weight})
 		}
 	}
 
@@ -0,0 +1,184 @@
+package loader
+
+import (
+	"bufio"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+	"github.com/MrFlynn/thesaurize/internal/transformer"
+	"github.com/google/go-cmp/cmp"
+)
+
+const testData = `UTF-8
+hello|1
+(noun)|hullo
+quick|2
+(adj)|speedy
+(adv)|speedily
+fox|1
+(noun)|dodger
+`
+
+func loadTestData(t *testing.T) *database.MemoryStore {
+	t.Helper()
+
+	var (
+		store = database.NewMemoryStore()
+		ch    = make(chan database.Entry)
+		done  = make(chan error)
+	)
+
+	go func() {
+		done <- pushToStore(context.Background(), store, ch, 2)
+	}()
+
+	words, err := scanDataFile(context.Background(), strings.NewReader(testData), ch, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.SendReady(context.Background(), words); err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
+
+func TestReadIntoMemoryStore(t *testing.T) {
+	store := loadTestData(t)
+
+	if err := store.WaitForReady(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := store.GetDatasetInfo(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Version != 1 || info.Words != 3 {
+		t.Errorf("Expected version 1 with 3 words\n Got %s", info)
+	}
+
+	synonyms, err := store.GetSynonyms(context.Background(), "quick", database.Adverb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{"speedily"}; !cmp.Equal(synonyms, expected) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected, synonyms)
+	}
+}
+
+func TestTransformFromMemoryStore(t *testing.T) {
+	store := loadTestData(t)
+
+	result := transformer.Transform(context.Background(), "Hello, the quick brown fox!", store, transformer.Options{SkipCommon: true})
+	if expected := "Hullo, the speedy brown dodger!"; result != expected {
+		t.Errorf("Expected %s\n Got %s", expected, result)
+	}
+}
+
+func TestScanDataFileCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ch := make(chan database.Entry)
+	go func() {
+		for range ch {
+		}
+	}()
+
+	words, err := scanDataFile(ctx, strings.NewReader(testData), ch, nil)
+	if err != context.Canceled {
+		t.Errorf("Expected %v\n Got %v", context.Canceled, err)
+	}
+
+	if words != 0 {
+		t.Errorf("Expected 0 words\n Got %d", words)
+	}
+}
+
+func TestReadSynonymsWeights(t *testing.T) {
+	scanner := bufio.NewScanner(strings.NewReader("run|3\n(verb)|sprint|jog\n(noun)|dash\n(verb)|jog|race\n"))
+	scanner.Scan()
+
+	word, synonyms, err := readSynonyms(scanner, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[relatedWords][]database.Synonym{
+		{lexeme: "verb"}: {{Word: "sprint", Weight: 1}, {Word: "jog", Weight: 1}, {Word: "race", Weight: 0.5}},
+		{lexeme: "noun"}: {{Word: "dash", Weight: 1}},
+	}
+
+	if word != "run" || !cmp.Equal(synonyms, expected, cmp.AllowUnexported(relatedWords{})) {
+		t.Errorf("Expected: %+v\n Got: %s %+v\n", expected, word, synonyms)
+	}
+}
+
+func TestReadSynonymsQualifiers(t *testing.T) {
+	data := "ill|2\n(adj)|sick|well (antonym)|unwell (similar term)\n(noun)|illness|ailment (generic term)|pass (a test)\n"
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	scanner.Scan()
+
+	_, synonyms, err := readSynonyms(scanner, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[relatedWords][]database.Synonym{
+		{lexeme: "adj"}: {{Word: "sick", Weight: 1}},
+		{lexeme: "adj", relation: database.Antonym}:     {{Word: "well", Weight: 1}},
+		{lexeme: "adj", relation: database.SimilarTerm}: {{Word: "unwell", Weight: 1}},
+		{lexeme: "noun"}: {{Word: "illness", Weight: 1}, {Word: "pass (a test)", Weight: 1}},
+		{lexeme: "noun", relation: database.GenericTerm}: {{Word: "ailment", Weight: 1}},
+	}
+
+	if !cmp.Equal(synonyms, expected, cmp.AllowUnexported(relatedWords{})) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected, synonyms)
+	}
+}
+
+func TestScanDataFileSpecificTerms(t *testing.T) {
+	data := "UTF-8\ndog|1\n(noun)|domestic dog|canine (generic term)\n"
+
+	ch := make(chan database.Entry)
+	entries := make(chan []database.Entry)
+
+	go func() {
+		var collected []database.Entry
+		for e := range ch {
+			collected = append(collected, e)
+		}
+
+		entries <- collected
+	}()
+
+	if _, err := scanDataFile(context.Background(), strings.NewReader(data), ch, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := database.Entry{
+		Word:     "canine",
+		Lexeme:   database.Noun,
+		Relation: database.SpecificTerm,
+		Synonyms: []database.Synonym{{Word: "dog", Weight: 1}},
+	}
+
+	for _, e := range <-entries {
+		if cmp.Equal(e, expected) {
+			return
+		}
+	}
+
+	t.Errorf("Expected entry %+v", expected)
+}
@@ -2,7 +2,7 @@ package loader
 
 import (
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
 	"regexp"
 )
@@ -39,8 +39,14 @@ type profanityFilter struct {
 
 func (f *profanityFilter) init(url string) error {
 	response, err := http.Get(url)
-	if err != nil || response.StatusCode == http.StatusNotFound {
-		return errors.New("unable to get filter index")
+	if err != nil {
+		return fmt.Errorf("unable to get filter index: %w", err)
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unable to get filter index, got status %s", response.Status)
 	}
 
 	return json.NewDecoder(response.Body).Decode(&f.index)
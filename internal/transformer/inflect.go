@@ -0,0 +1,400 @@
+package transformer
+
+import (
+	"strings"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+)
+
+// inflection is a change made to the base form of a word.
+type inflection int
+
+const (
+	noInflection inflection = iota
+	// Plural nouns and third person singular verbs (-s).
+	sInflection
+	// Simple past (-ed).
+	pastInflection
+	// Past participle (-ed, or -en for some irregular verbs).
+	participleInflection
+	// Present participle (-ing).
+	ingInflection
+	comparativeInflection
+	superlativeInflection
+	// Adverbs formed from adjectives (-ly).
+	lyInflection
+)
+
+// Words shorter than this are never stemmed.
+const minStemLength = 4
+
+// lemma is a possible base form of a word along with the inflection that was
+// applied to it and the lexemes the base form is used as.
+type lemma struct {
+	base       string
+	inflection inflection
+	lexemes    []database.Lexeme
+}
+
+var (
+	nounLexemes      = []database.Lexeme{database.Noun, database.Verb}
+	verbLexemes      = []database.Lexeme{database.Verb}
+	adjectiveLexemes = []database.Lexeme{database.Adjective}
+)
+
+// Irregular verbs as base, simple past and past participle.
+var irregularVerbs = [][3]string{
+	{"be", "was", "been"}, {"begin", "began", "begun"}, {"bite", "bit", "bitten"},
+	{"break", "broke", "broken"}, {"bring", "brought", "brought"},
+	{"build", "built", "built"}, {"buy", "bought", "bought"},
+	{"catch", "caught", "caught"}, {"choose", "chose", "chosen"},
+	{"come", "came", "come"}, {"do", "did", "done"}, {"draw", "drew", "drawn"},
+	{"drink", "drank", "drunk"}, {"drive", "drove", "driven"},
+	{"eat", "ate", "eaten"}, {"fall", "fell", "fallen"}, {"feel", "felt", "felt"},
+	{"fight", "fought", "fought"}, {"find", "found", "found"},
+	{"fly", "flew", "flown"}, {"forget", "forgot", "forgotten"},
+	{"get", "got", "gotten"}, {"give", "gave", "given"}, {"go", "went", "gone"},
+	{"grow", "grew", "grown"}, {"hear", "heard", "heard"},
+	{"hide", "hid", "hidden"}, {"hold", "held", "held"}, {"keep", "kept", "kept"},
+	{"know", "knew", "known"}, {"lead", "led", "led"}, {"leave", "left", "left"},
+	{"lose", "lost", "lost"}, {"make", "made", "made"}, {"meet", "met", "met"},
+	{"pay", "paid", "paid"}, {"ride", "rode", "ridden"}, {"rise", "rose", "risen"},
+	{"run", "ran", "run"}, {"say", "said", "said"}, {"see", "saw", "seen"},
+	{"sell", "sold", "sold"}, {"send", "sent", "sent"},
+	{"shake", "shook", "shaken"}, {"sing", "sang", "sung"}, {"sit", "sat", "sat"},
+	{"sleep", "slept", "slept"}, {"speak", "spoke", "spoken"},
+	{"spend", "spent", "spent"}, {"stand", "stood", "stood"},
+	{"steal", "stole", "stolen"}, {"swim", "swam", "swum"},
+	{"take", "took", "taken"}, {"teach", "taught", "taught"},
+	{"tell", "told", "told"}, {"think", "thought", "thought"},
+	{"throw", "threw", "thrown"}, {"understand", "understood", "understood"},
+	{"wake", "woke", "woken"}, {"wear", "wore", "worn"}, {"win", "won", "won"},
+	{"write", "wrote", "written"},
+}
+
+// Irregular nouns as singular and plural.
+var irregularNouns = [][2]string{
+	{"child", "children"}, {"foot", "feet"}, {"goose", "geese"},
+	{"man", "men"}, {"mouse", "mice"}, {"person", "people"}, {"tooth", "teeth"},
+	{"woman", "women"},
+}
+
+// Irregular adjectives as base, comparative and superlative.
+var irregularAdjectives = [][3]string{
+	{"good", "better", "best"}, {"bad", "worse", "worst"},
+	{"far", "farther", "farthest"}, {"little", "less", "least"},
+	{"many", "more", "most"},
+}
+
+var (
+	// Irregular inflected forms mapped to their lemma.
+	irregularLemmas = make(map[string]lemma)
+	// Irregular forms of each base word by inflection.
+	irregularForms = make(map[inflection]map[string]string)
+)
+
+func init() {
+	add := func(base, form string, infl inflection, lexemes []database.Lexeme) {
+		if _, ok := irregularLemmas[form]; !ok && form != base {
+			irregularLemmas[form] = lemma{base: base, inflection: infl, lexemes: lexemes}
+		}
+
+		if irregularForms[infl] == nil {
+			irregularForms[infl] = make(map[string]string)
+		}
+
+		irregularForms[infl][base] = form
+	}
+
+	for _, v := range irregularVerbs {
+		add(v[0], v[1], pastInflection, verbLexemes)
+		add(v[0], v[2], participleInflection, verbLexemes)
+	}
+
+	for _, n := range irregularNouns {
+		add(n[0], n[1], sInflection, []database.Lexeme{database.Noun})
+	}
+
+	for _, a := range irregularAdjectives {
+		add(a[0], a[1], comparativeInflection, adjectiveLexemes)
+		add(a[0], a[2], superlativeInflection, adjectiveLexemes)
+	}
+}
+
+// lemmas returns the possible base forms of an inflected word, most likely
+// first. Words that don't look inflected have no lemmas.
+func lemmas(word string) []lemma {
+	if l, ok := irregularLemmas[word]; ok {
+		return []lemma{l}
+	}
+
+	if strings.Contains(word, " ") {
+		return phraseLemmas(word)
+	}
+
+	if len(word) < minStemLength {
+		return nil
+	}
+
+	var results []lemma
+	add := func(infl inflection, lexemes []database.Lexeme, bases ...string) {
+		for _, base := range bases {
+			if len(base) >= 2 {
+				results = append(results, lemma{base: base, inflection: infl, lexemes: lexemes})
+			}
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		add(sInflection, nounLexemes, word[:len(word)-3]+"y")
+	case strings.HasSuffix(word, "es"):
+		add(sInflection, nounLexemes, word[:len(word)-2], word[:len(word)-1])
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		add(sInflection, nounLexemes, word[:len(word)-1])
+	case strings.HasSuffix(word, "ied"):
+		add(pastInflection, verbLexemes, word[:len(word)-3]+"y")
+	case strings.HasSuffix(word, "ed"):
+		add(pastInflection, verbLexemes, stems(word[:len(word)-2])...)
+	case strings.HasSuffix(word, "ing"):
+		add(ingInflection, verbLexemes, stems(word[:len(word)-3])...)
+	case strings.HasSuffix(word, "iest"):
+		add(superlativeInflection, adjectiveLexemes, word[:len(word)-4]+"y")
+	case strings.HasSuffix(word, "est"):
+		add(superlativeInflection, adjectiveLexemes, stems(word[:len(word)-3])...)
+	case strings.HasSuffix(word, "ier"):
+		add(comparativeInflection, adjectiveLexemes, word[:len(word)-3]+"y")
+	case strings.HasSuffix(word, "er"):
+		add(comparativeInflection, adjectiveLexemes, stems(word[:len(word)-2])...)
+	case strings.HasSuffix(word, "ily"):
+		add(lyInflection, adjectiveLexemes, word[:len(word)-3]+"y")
+	case strings.HasSuffix(word, "ally"):
+		add(lyInflection, adjectiveLexemes, word[:len(word)-4], word[:len(word)-2])
+	case strings.HasSuffix(word, "ly"):
+		add(lyInflection, adjectiveLexemes, word[:len(word)-2], word[:len(word)-2]+"e")
+	}
+
+	return results
+}
+
+// phraseLemmas returns the possible base forms of a phrase. Verb phrases such
+// as "gave up" are inflected on their first word and noun phrases such as
+// "ice creams" on their last.
+func phraseLemmas(phrase string) []lemma {
+	var (
+		results []lemma
+		words   = strings.Split(phrase, " ")
+		head    = strings.Join(words[:len(words)-1], " ")
+		tail    = strings.Join(words[1:], " ")
+	)
+
+	for _, l := range lemmas(words[0]) {
+		if !containsLexeme(l.lexemes, database.Verb) {
+			continue
+		}
+
+		results = append(results, lemma{base: l.base + " " + tail, inflection: l.inflection, lexemes: verbLexemes})
+	}
+
+	for _, l := range lemmas(words[len(words)-1]) {
+		if l.inflection == sInflection {
+			results = append(results, lemma{base: head + " " + l.base, inflection: l.inflection, lexemes: []database.Lexeme{database.Noun}})
+		}
+	}
+
+	return results
+}
+
+func containsLexeme(lexemes []database.Lexeme, lexeme database.Lexeme) bool {
+	for _, l := range lexemes {
+		if l == lexeme {
+			return true
+		}
+	}
+
+	return false
+}
+
+// stems returns the possible base forms of a word whose suffix starting with
+// a vowel was removed: the stem itself, the stem with a doubled final
+// consonant undone and the stem with a silent e restored.
+func stems(stem string) []string {
+	// The final consonant of a stem like "hop" would have been doubled, so
+	// "hoped" most likely comes from "hope".
+	if doubleFinal(stem) != stem {
+		return []string{stem + "e", stem}
+	}
+
+	results := []string{stem, stem + "e"}
+
+	if n := len(stem); n >= 3 && stem[n-1] == stem[n-2] && !isVowel(stem[n-1]) &&
+		!strings.ContainsRune("lsz", rune(stem[n-1])) {
+		results = append([]string{stem[:n-1]}, results...)
+	}
+
+	return results
+}
+
+// inflect applies an inflection to the base form of a word used as the given
+// lexeme. Verb phrases inflect their first word and noun phrases their last.
+func inflect(word string, infl inflection, lexeme database.Lexeme) string {
+	if infl == noInflection {
+		return word
+	}
+
+	words := strings.Split(word, " ")
+	if len(words) > 1 {
+		switch infl {
+		case comparativeInflection:
+			return "more " + word
+		case superlativeInflection:
+			return "most " + word
+		case lyInflection:
+			return word
+		}
+
+		head := len(words) - 1
+		if lexeme == database.Verb {
+			head = 0
+		}
+
+		words[head] = inflect(words[head], infl, lexeme)
+
+		return strings.Join(words, " ")
+	}
+
+	if form, ok := irregularForms[infl][word]; ok {
+		return form
+	}
+
+	switch infl {
+	case sInflection:
+		switch {
+		case hasSibilantEnding(word):
+			return word + "es"
+		case endsInConsonantY(word):
+			return word[:len(word)-1] + "ies"
+		default:
+			return word + "s"
+		}
+	case pastInflection, participleInflection:
+		switch {
+		case strings.HasSuffix(word, "e"):
+			return word + "d"
+		case endsInConsonantY(word):
+			return word[:len(word)-1] + "ied"
+		default:
+			return doubleFinal(word) + "ed"
+		}
+	case ingInflection:
+		switch {
+		case strings.HasSuffix(word, "ie"):
+			return word[:len(word)-2] + "ying"
+		case strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "ee") && len(word) > 2:
+			return word[:len(word)-1] + "ing"
+		default:
+			return doubleFinal(word) + "ing"
+		}
+	case comparativeInflection, superlativeInflection:
+		suffix, long := "er", "more "
+		if infl == superlativeInflection {
+			suffix, long = "est", "most "
+		}
+
+		switch syllables := countSyllables(word); {
+		case endsInConsonantY(word) && syllables <= 2:
+			return word[:len(word)-1] + "i" + suffix
+		case syllables > 1:
+			return long + word
+		case strings.HasSuffix(word, "e"):
+			return word + suffix[1:]
+		default:
+			return doubleFinal(word) + suffix
+		}
+	case lyInflection:
+		switch {
+		case strings.HasSuffix(word, "ly"):
+			return word
+		case endsInConsonantY(word):
+			return word[:len(word)-1] + "ily"
+		case strings.HasSuffix(word, "le"):
+			return word[:len(word)-1] + "y"
+		case strings.HasSuffix(word, "ic"):
+			return word + "ally"
+		default:
+			return word + "ly"
+		}
+	}
+
+	return word
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aeiou", c) >= 0
+}
+
+// isVowelRune is like isVowel but also accepts accented vowels, as found in
+// loanwords such as "café".
+func isVowelRune(r rune) bool {
+	return strings.ContainsRune("aeiouàáâäèéêëìíîïòóôöùúûü", r)
+}
+
+func hasSibilantEnding(word string) bool {
+	for _, suffix := range []string{"s", "x", "z", "ch", "sh"} {
+		if strings.HasSuffix(word, suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func endsInConsonantY(word string) bool {
+	n := len(word)
+	return n >= 2 && word[n-1] == 'y' && !isVowel(word[n-2])
+}
+
+// doubleFinal doubles the final consonant of single syllable words ending in
+// a consonant, vowel, consonant sequence such as "run" or "big".
+func doubleFinal(word string) string {
+	n := len(word)
+	if n < 3 || countSyllables(word) != 1 {
+		return word
+	}
+
+	last := word[n-1]
+	if isVowel(last) || strings.IndexByte("wxy", last) >= 0 || !isVowel(word[n-2]) || isVowel(word[n-3]) {
+		return word
+	}
+
+	return word + string(last)
+}
+
+// countSyllables estimates the number of syllables in a word by counting
+// groups of vowels, ignoring a silent final e.
+func countSyllables(word string) int {
+	var (
+		count     int
+		prevVowel bool
+	)
+
+	for i, r := range word {
+		vowel := isVowelRune(r) || (r == 'y' && i > 0)
+		if vowel && !prevVowel {
+			count++
+		}
+
+		prevVowel = vowel
+	}
+
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+
+	if count == 0 {
+		return 1
+	}
+
+	return count
+}
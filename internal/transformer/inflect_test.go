@@ -0,0 +1,117 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+)
+
+func TestLemmas(t *testing.T) {
+	tests := []struct {
+		word       string
+		base       string
+		inflection inflection
+	}{
+		{"cats", "cat", sInflection},
+		{"boxes", "box", sInflection},
+		{"cities", "city", sInflection},
+		{"walked", "walk", pastInflection},
+		{"hoped", "hope", pastInflection},
+		{"stopped", "stop", pastInflection},
+		{"tried", "try", pastInflection},
+		{"running", "run", ingInflection},
+		{"making", "make", ingInflection},
+		{"bigger", "big", comparativeInflection},
+		{"happiest", "happy", superlativeInflection},
+		{"quickly", "quick", lyInflection},
+		{"ran", "run", pastInflection},
+		{"children", "child", sInflection},
+	}
+
+	for _, test := range tests {
+		var found *lemma
+		for _, l := range lemmas(test.word) {
+			if l.base == test.base && l.inflection == test.inflection {
+				found = &l
+				break
+			}
+		}
+
+		if found == nil {
+			t.Errorf("Expected %s to be a lemma of %s\n Got %+v", test.base, test.word, lemmas(test.word))
+			continue
+		}
+
+		// Inflecting the lemma again has to give back the original word.
+		if result := inflect(found.base, found.inflection, found.lexemes[0]); result != test.word {
+			t.Errorf("Expected %s to inflect back to %s\n Got %s", test.base, test.word, result)
+		}
+	}
+
+	if l := lemmas("cat"); len(l) != 0 {
+		t.Errorf("Expected no lemmas for short words\n Got %+v", l)
+	}
+}
+
+func TestInflect(t *testing.T) {
+	tests := []struct {
+		word       string
+		inflection inflection
+		lexeme     database.Lexeme
+		expected   string
+	}{
+		{"hound", sInflection, database.Noun, "hounds"},
+		{"fox", sInflection, database.Noun, "foxes"},
+		{"lady", sInflection, database.Noun, "ladies"},
+		{"ice cream", sInflection, database.Noun, "ice creams"},
+		{"dash", sInflection, database.Verb, "dashes"},
+		{"stroll", pastInflection, database.Verb, "strolled"},
+		{"jog", pastInflection, database.Verb, "jogged"},
+		{"amble", pastInflection, database.Verb, "ambled"},
+		{"hurry", pastInflection, database.Verb, "hurried"},
+		{"run", pastInflection, database.Verb, "ran"},
+		{"look into", pastInflection, database.Verb, "looked into"},
+		{"jog", ingInflection, database.Verb, "jogging"},
+		{"amble", ingInflection, database.Verb, "ambling"},
+		{"tie", ingInflection, database.Verb, "tying"},
+		{"large", comparativeInflection, database.Adjective, "larger"},
+		{"big", comparativeInflection, database.Adjective, "bigger"},
+		{"happy", superlativeInflection, database.Adjective, "happiest"},
+		{"enormous", comparativeInflection, database.Adjective, "more enormous"},
+		{"good", superlativeInflection, database.Adjective, "best"},
+		{"speedy", lyInflection, database.Adjective, "speedily"},
+		{"swift", lyInflection, database.Adjective, "swiftly"},
+		{"gentle", lyInflection, database.Adjective, "gently"},
+	}
+
+	for _, test := range tests {
+		if result := inflect(test.word, test.inflection, test.lexeme); result != test.expected {
+			t.Errorf("Expected %s\n Got %s", test.expected, result)
+		}
+	}
+}
+
+func TestTransformInflected(t *testing.T) {
+	store := lexemeStore{
+		synonyms: map[string]map[database.Lexeme]string{
+			"cat":   {database.Noun: "feline"},
+			"walk":  {database.Noun: "stroll", database.Verb: "amble"},
+			"quick": {database.Adjective: "swift"},
+			"big":   {database.Adjective: "large"},
+			"dog":   {database.Noun: "hound"},
+		},
+	}
+
+	tests := map[string]string{
+		"Cats walked quickly":      "Felines ambled swiftly",
+		"Bigger dogs are walking.": "Larger hounds are ambling.",
+		"Two walks":                "Two strolls",
+	}
+
+	for message, expected := range tests {
+		if result := Transform(context.Background(), message, store, Options{}); result != expected {
+			t.Errorf("Expected %s\n Got %s", expected, result)
+		}
+	}
+}
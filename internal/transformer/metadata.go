@@ -13,18 +13,65 @@ const (
 	noCapitalization capitalization = iota
 	firstCapitzlized
 	allCapitalized
+	// Every word of a phrase is capitalized.
+	titleCapitalized
+	// Upper case letters anywhere in a word other than the first letter, such
+	// as "iPhone" or "McDonald". The case of every letter is kept in a mask.
+	mixedCapitalized
 )
 
-// Regexes for handling how to split messages into usable components.
-var wordSplitRegex = regexp.MustCompile(`(\w+\b-+|\S+)[\n]*`)
-var punctuationRegex = regexp.MustCompile(`^(\W+)|(\W+)$`)
-var capitalRegex = regexp.MustCompile(`\b[A-Z]+`)
+// Matches words ending in a contraction or the possessive "'s".
+var cliticRegex = regexp.MustCompile(`(?i)^(.*[\p{L}\p{M}\p{N}])(n['’]t|['’](?:s|re|ll|ve|d|m))$`)
+
+// Contractions that are kept whole, either because splitting them doesn't
+// leave a word behind or because their "'s" stands for "us".
+var wholeContractions = map[string]struct{}{
+	"can't": {}, "won't": {}, "shan't": {}, "ain't": {}, "let's": {},
+}
+
+// Punctuation ending a sentence, possibly followed by closing quotes or
+// brackets.
+var sentenceEndRegex = regexp.MustCompile(`[.!?…][\p{Pe}\p{Pf}"']*$`)
+
+// Regexes for handling how to split messages into usable components. Words
+// are made up of letters, combining marks and digits of any script. Anything
+// else at either end of a word, such as curly quotes, is punctuation, while
+// characters inside a word, like apostrophes, are kept.
+var wordSplitRegex = regexp.MustCompile(`[\p{L}\p{M}\p{N}]+-+|\S+`)
+var punctuationRegex = regexp.MustCompile(`^([^\p{L}\p{M}\p{N}]+)|([^\p{L}\p{M}\p{N}]+)$`)
+
+// Regexes for Discord markup that has to be carried through untouched. A
+// message is first split into chunks separated by whitespace, except that
+// code spans are kept whole even if they contain whitespace. Chunks
+// containing code, mentions, custom emoji, timestamps or links are verbatim.
+// Formatting such as bold, italics and spoilers is treated as punctuation so
+// the words inside it are still thesaurized.
+var chunkRegex = regexp.MustCompile("(?:```[\\s\\S]*?```|`[^`\\n]+`|\\S)+")
+var verbatimRegex = regexp.MustCompile(
+	"```[\\s\\S]*?```|`[^`\\n]+`" + // Code blocks and inline code.
+		`|</?[@#:!&\w]*\d+>` + // Mentions of users, roles, channels and commands.
+		`|<a?:\w+:\d+>` + // Custom emoji.
+		`|<t:-?\d+(?::\w)?>` + // Timestamps.
+		`|\b[a-zA-Z][a-zA-Z0-9+.-]*://\S+`, // Links.
+)
 
 // WordMetadata is individual word metadata information.
 type WordMetadata struct {
 	Capitalization capitalization
-	PrePunc        string
-	PostPunc       string
+	// CaseMask records which letters of a word with mixed capitalization
+	// are upper case.
+	CaseMask []bool
+	// MaskedWord is the lower case word the case mask was taken from.
+	MaskedWord string
+	// Clitic is a contraction or possessive ending split off the word as
+	// written, such as "n't" or "'s". A lone apostrophe marks the possessive
+	// of a plural.
+	Clitic   string
+	PrePunc  string
+	PostPunc string
+	// Verbatim words are Discord markup that is never looked up or replaced,
+	// and are kept exactly as written.
+	Verbatim bool
 }
 
 // This method might look a little contrived with the number of "if" statements
@@ -55,63 +102,287 @@ func createWordMetadata(word string) (*WordMetadata, string) {
 		word = punctuationRegex.ReplaceAllLiteralString(word, "")
 	}
 
-	switch len(capitalRegex.FindString(word)) {
-	case 0:
+	if base, clitic, ok := splitClitic(word); ok {
+		if meta == nil {
+			meta = &WordMetadata{}
+		}
+
+		meta.Clitic = clitic
+		word = base
+	} else if meta != nil && isPluralPossessive(word, meta.PrePunc, meta.PostPunc) {
+		_, size := utf8.DecodeRuneInString(meta.PostPunc)
+		meta.Clitic, meta.PostPunc = meta.PostPunc[:size], meta.PostPunc[size:]
+	}
+
+	switch c, mask := capitalizationOf(word); c {
+	case noCapitalization:
 		if meta != nil {
 			meta.Capitalization = noCapitalization
 		}
-	case 1:
+	case mixedCapitalized:
 		if meta == nil {
 			meta = &WordMetadata{}
 		}
 
-		meta.Capitalization = firstCapitzlized
+		meta.Capitalization = mixedCapitalized
 		word = strings.ToLower(word)
+		meta.CaseMask, meta.MaskedWord = mask, word
 	default:
 		if meta == nil {
 			meta = &WordMetadata{}
 		}
 
-		meta.Capitalization = allCapitalized
+		meta.Capitalization = c
 		word = strings.ToLower(word)
 	}
 
 	return meta, word
 }
 
+// capitalizationOf classifies a word by the case of its letters. Words with
+// only their first letter in upper or title case are capitalized and words
+// of several letters that are all upper case are all capitals. Any other
+// word with upper case letters has mixed capitalization, described by a mask
+// with an entry for every rune of the word. Words in scripts without case are
+// never capitalized.
+func capitalizationOf(word string) (capitalization, []bool) {
+	var (
+		letters, upper int
+		firstUpper     bool
+	)
+
+	for _, r := range word {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		isUpper := unicode.IsUpper(r) || unicode.IsTitle(r)
+		if letters == 0 {
+			firstUpper = isUpper
+		}
+
+		letters++
+		if isUpper {
+			upper++
+		}
+	}
+
+	switch {
+	case upper == 0:
+		return noCapitalization, nil
+	case upper == letters && letters > 1:
+		return allCapitalized, nil
+	case upper == 1 && firstUpper:
+		return firstCapitzlized, nil
+	}
+
+	mask := make([]bool, 0, len(word))
+	for _, r := range word {
+		mask = append(mask, unicode.IsUpper(r) || unicode.IsTitle(r))
+	}
+
+	return mixedCapitalized, mask
+}
+
 // MessageMetadata contains a list of words and their associated metadata.
 type MessageMetadata struct {
 	Words    []string
 	Metadata []*WordMetadata
-	size     uint32
+	// Spacing is the whitespace following each word exactly as it appeared
+	// in the message. Words are separated by single spaces if it's not set.
+	Spacing []string
+	// Whitespace before the first word.
+	leading string
+	size    uint32
 }
 
-// New initializes message metadata struct from a string.
+// New initializes message metadata struct from a string. The whitespace
+// between words is recorded so the message keeps its layout.
 func (m *MessageMetadata) New(message string) {
-	wordList := wordSplitRegex.FindAllString(message, -1)
+	chunks := chunkRegex.FindAllStringIndex(message, -1)
 
 	m.size = uint32(len(message))
-	m.Words = make([]string, len(wordList))
-	m.Metadata = make([]*WordMetadata, len(wordList))
+	m.Words = make([]string, 0, len(chunks))
+	m.Metadata = make([]*WordMetadata, 0, len(chunks))
+	m.Spacing = make([]string, 0, len(chunks))
+
+	m.leading = message
+	if len(chunks) > 0 {
+		m.leading = message[:chunks[0][0]]
+	}
+
+	// Whether a single quote opened by an earlier word is still open.
+	var quoted bool
+
+	for idx, loc := range chunks {
+		chunk := message[loc[0]:loc[1]]
+
+		next := len(message)
+		if idx+1 < len(chunks) {
+			next = chunks[idx+1][0]
+		}
+
+		spacing := message[loc[1]:next]
+
+		if verbatimRegex.MatchString(chunk) {
+			m.Words = append(m.Words, chunk)
+			m.Metadata = append(m.Metadata, &WordMetadata{Verbatim: true})
+			m.Spacing = append(m.Spacing, spacing)
+
+			continue
+		}
+
+		// Words split at hyphens aren't separated by any whitespace.
+		words := wordSplitRegex.FindAllString(chunk, -1)
+		for i, word := range words {
+			meta, normalizedWord := createWordMetadata(word)
+			quoted = closeQuote(meta, quoted)
+
+			m.Words = append(m.Words, normalizedWord)
+			m.Metadata = append(m.Metadata, meta)
+
+			if i == len(words)-1 {
+				m.Spacing = append(m.Spacing, spacing)
+			} else {
+				m.Spacing = append(m.Spacing, "")
+			}
+		}
+	}
+}
+
+// spacing returns the whitespace following the word at idx.
+func (m MessageMetadata) spacing(idx int) string {
+	switch {
+	case idx < len(m.Spacing):
+		return m.Spacing[idx]
+	case idx == len(m.Words)-1:
+		return ""
+	default:
+		return " "
+	}
+}
+
+// endsLine reports whether a line break follows the word at idx.
+func (m MessageMetadata) endsLine(idx int) bool {
+	return strings.Contains(m.spacing(idx), "\n")
+}
+
+// verbatim reports whether the word at idx has to be kept as written.
+func (m MessageMetadata) verbatim(idx int) bool {
+	return m.Metadata[idx] != nil && m.Metadata[idx].Verbatim
+}
+
+// join merges the words from start up to but excluding end into a single
+// phrase. The phrase keeps the leading punctuation and capitalization of its
+// first word and the clitic and trailing punctuation of its last word.
+func (m *MessageMetadata) join(start, end int) {
+	if end-start < 2 {
+		return
+	}
+
+	var (
+		first = m.Metadata[start]
+		last  = m.Metadata[end-1]
+		meta  *WordMetadata
+	)
+
+	if first != nil || last != nil {
+		meta = &WordMetadata{}
+
+		if first != nil {
+			meta.Capitalization = m.phraseCapitalization(start, end)
+			meta.CaseMask, meta.MaskedWord = first.CaseMask, first.MaskedWord
+			meta.PrePunc = first.PrePunc
+		}
+
+		if last != nil {
+			meta.Clitic = last.Clitic
+			meta.PostPunc = last.PostPunc
+		}
+	}
+
+	m.Words[start] = strings.Join(m.Words[start:end], " ")
+	m.Metadata[start] = meta
+
+	m.Words = append(m.Words[:start+1], m.Words[end:]...)
+	m.Metadata = append(m.Metadata[:start+1], m.Metadata[end:]...)
+
+	if end <= len(m.Spacing) {
+		m.Spacing[start] = m.Spacing[end-1]
+		m.Spacing = append(m.Spacing[:start+1], m.Spacing[end:]...)
+	}
+}
 
-	for idx, word := range wordList {
-		meta, normalizedWord := createWordMetadata(word)
+// phraseCapitalization returns the capitalization of the phrase made up of
+// the words from start up to but excluding end. A phrase is in title case if
+// every word in it is capitalized, otherwise it is capitalized like its first
+// word.
+func (m MessageMetadata) phraseCapitalization(start, end int) capitalization {
+	var capitalized, all int
 
-		m.Words[idx] = normalizedWord
-		m.Metadata[idx] = meta
+	for _, meta := range m.Metadata[start:end] {
+		if meta == nil {
+			continue
+		}
+
+		switch meta.Capitalization {
+		case allCapitalized:
+			all++
+			capitalized++
+		case firstCapitzlized:
+			capitalized++
+		}
+	}
+
+	switch n := end - start; {
+	case all == n:
+		return allCapitalized
+	case capitalized == n:
+		return titleCapitalized
+	case m.Metadata[start] == nil:
+		return noCapitalization
+	default:
+		return m.Metadata[start].Capitalization
 	}
 }
 
+// sentenceStart reports whether the word at idx starts a sentence: it is the
+// first word of the message or of a line, or follows a full stop, question
+// mark or exclamation mark.
+func (m MessageMetadata) sentenceStart(idx int) bool {
+	if idx == 0 || m.endsLine(idx-1) {
+		return true
+	}
+
+	prev := m.Metadata[idx-1]
+
+	return prev != nil && sentenceEndRegex.MatchString(prev.PostPunc)
+}
+
+// capitalize restores the capitalization of the word at idx onto word, which
+// may be a replacement of a different length or number of words. A
+// capitalized word starting a sentence only has its first letter capitalized.
+// Anywhere else it is likely a name or title, so every word of a phrase
+// replacing it is capitalized.
 func (m MessageMetadata) capitalize(word string, idx int) string {
-	if m.Metadata[idx] == nil {
+	meta := m.Metadata[idx]
+	if meta == nil {
 		return word
 	}
 
-	switch m.Metadata[idx].Capitalization {
+	switch meta.Capitalization {
 	case firstCapitzlized:
-		return capitalizeFirst(word)
+		if m.sentenceStart(idx) {
+			return capitalizeFirst(word)
+		}
+
+		return capitalizeWords(word)
+	case titleCapitalized:
+		return capitalizeWords(word)
 	case allCapitalized:
 		return strings.ToUpper(word)
+	case mixedCapitalized:
+		return applyCaseMask(word, meta, m.sentenceStart(idx))
 	default:
 		return word
 	}
@@ -124,23 +395,23 @@ func (m MessageMetadata) String() string {
 	// Grow the buffer so that we have some headroom over the original string.
 	builder.Grow(int(1.2 * float32(m.size)))
 
+	builder.WriteString(m.leading)
+	builder.Flush()
+
 	for idx, word := range m.Words {
 		if meta := m.Metadata[idx]; meta != nil {
 			builder.WriteString(meta.PrePunc)
-			builder.WriteString(m.capitalize(word, idx))
+			builder.WriteString(attachClitic(m.capitalize(word, idx), meta.Clitic))
 			builder.WriteString(meta.PostPunc)
-
-			if !strings.HasSuffix(meta.PostPunc, "\n") {
-				builder.WriteString(" ")
-			}
 		} else {
 			builder.WriteString(word)
-			builder.WriteString(" ")
 		}
 
+		builder.WriteString(m.spacing(idx))
+
 		if builder.Len() >= 1997 {
 			builder.Reverse(-1)
-			builder.WriteString("....")
+			builder.WriteString("...")
 
 			break
 		}
@@ -148,14 +419,14 @@ func (m MessageMetadata) String() string {
 		builder.Flush()
 	}
 
-	return builder.String()[:builder.Len()-1]
+	return builder.String()
 }
 
 func capitalizeFirst(s string) string {
 	if len(s) > 0 {
 		r, sz := utf8.DecodeRuneInString(s)
 		if r != utf8.RuneError || sz > 1 {
-			upper := unicode.ToUpper(r)
+			upper := unicode.ToTitle(r)
 			if upper != r {
 				s = string(upper) + s[sz:]
 			}
@@ -164,3 +435,118 @@ func capitalizeFirst(s string) string {
 
 	return s
 }
+
+// capitalizeWords capitalizes the first letter of every word in a phrase.
+func capitalizeWords(s string) string {
+	words := strings.Split(s, " ")
+	for i, word := range words {
+		words[i] = capitalizeFirst(word)
+	}
+
+	return strings.Join(words, " ")
+}
+
+// applyCaseMask upper cases the letters of word marked in the case mask of
+// meta. The mask only fits the word it was taken from, so any replacement is
+// capitalized like the first letter of the mask, or because it starts a
+// sentence.
+func applyCaseMask(word string, meta *WordMetadata, sentenceStart bool) string {
+	mask := meta.CaseMask
+
+	if runes := []rune(word); strings.ToLower(word) == meta.MaskedWord && len(runes) == len(mask) {
+		for i, upper := range mask {
+			if upper {
+				runes[i] = unicode.ToUpper(runes[i])
+			}
+		}
+
+		return string(runes)
+	}
+
+	if sentenceStart || len(mask) > 0 && mask[0] {
+		return capitalizeFirst(word)
+	}
+
+	return word
+}
+
+// splitClitic splits a contraction or the possessive "'s" off a word.
+func splitClitic(word string) (string, string, bool) {
+	match := cliticRegex.FindStringSubmatch(word)
+	if match == nil {
+		return word, "", false
+	}
+
+	normalized := strings.ReplaceAll(strings.ToLower(word), "’", "'")
+	if _, ok := wholeContractions[normalized]; ok {
+		return word, "", false
+	}
+
+	base, clitic := match[1], match[2]
+
+	return base, clitic, true
+}
+
+// isPluralPossessive reports whether the punctuation following a word starts
+// with the apostrophe of a plural possessive like "dogs'". An apostrophe
+// before the word means it closes a quote instead, like in "'yes'".
+func isPluralPossessive(word, pre, post string) bool {
+	if strings.ContainsAny(pre, "'‘’") {
+		return false
+	}
+
+	r, _ := utf8.DecodeRuneInString(post)
+	return (r == '\'' || r == '’') && strings.HasSuffix(strings.ToLower(word), "s")
+}
+
+// closeQuote turns the apostrophe split off a word as a plural possessive
+// back into punctuation if it closes a single quote opened by an earlier
+// word, like in "'feed the dogs'". It reports whether a quote is still open
+// after the word.
+func closeQuote(meta *WordMetadata, quoted bool) bool {
+	if meta == nil {
+		return quoted
+	}
+
+	if strings.ContainsAny(meta.PrePunc, "'‘’") {
+		quoted = true
+	}
+
+	if !quoted {
+		return false
+	}
+
+	if utf8.RuneCountInString(meta.Clitic) == 1 {
+		meta.Clitic, meta.PostPunc = "", meta.Clitic+meta.PostPunc
+	}
+
+	return !strings.ContainsAny(meta.PostPunc, "'’")
+}
+
+func isNegation(clitic string) bool {
+	return strings.HasPrefix(strings.ToLower(clitic), "n")
+}
+
+// negated reports whether the word at idx carries "n't". Such words are
+// auxiliaries, which can't be swapped for one another without changing the
+// meaning or grammar of the sentence, so they are never replaced.
+func (m MessageMetadata) negated(idx int) bool {
+	return m.Metadata[idx] != nil && isNegation(m.Metadata[idx].Clitic)
+}
+
+// attachClitic reattaches a clitic split off a word to its replacement. The
+// possessive of a plural not ending in "s" takes an "s" of its own.
+func attachClitic(word, clitic string) string {
+	switch {
+	case clitic == "":
+		return word
+	case utf8.RuneCountInString(clitic) == 1 && !strings.HasSuffix(strings.ToLower(word), "s"):
+		if last, _ := utf8.DecodeLastRuneInString(word); unicode.IsUpper(last) {
+			return word + clitic + "S"
+		}
+
+		return word + clitic + "s"
+	default:
+		return word + clitic
+	}
+}
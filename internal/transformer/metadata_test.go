@@ -303,3 +303,343 @@ func TestIntegrationWithReplacement(t *testing.T) {
 		t.Errorf("Expected %s\n Got %s", "Hi, my name is Jane.", meta.String())
 	}
 }
+
+func TestMarkupGenerateMetadataFromSentence(t *testing.T) {
+	meta := MessageMetadata{}
+	meta.New("Hey <@1234>, see `go run .` and ||__this__|| <:wave:5678> https://example.com/a?b=c\n```go\nfmt.Println()\n```")
+
+	expected := &MessageMetadata{
+		Words: []string{
+			"hey", "<@1234>,", "see", "`go run .`", "and", "this", "<:wave:5678>",
+			"https://example.com/a?b=c", "```go\nfmt.Println()\n```",
+		},
+		Metadata: []*WordMetadata{
+			{Capitalization: 1},
+			{Verbatim: true},
+			nil,
+			{Verbatim: true},
+			nil,
+			{PrePunc: "||__", PostPunc: "__||"},
+			{Verbatim: true},
+			{Verbatim: true},
+			{Verbatim: true},
+		},
+		Spacing: []string{" ", " ", " ", " ", " ", " ", " ", "\n", ""},
+	}
+
+	if !cmp.Equal(meta.Words, expected.Words) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected.Words, meta.Words)
+	}
+
+	if !cmp.Equal(meta.Metadata, expected.Metadata) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected.Metadata, meta.Metadata)
+	}
+
+	if !cmp.Equal(meta.Spacing, expected.Spacing) {
+		t.Errorf("Expected: %q\n Got: %q\n", expected.Spacing, meta.Spacing)
+	}
+}
+
+func TestMarkupIntegration(t *testing.T) {
+	message := "Hey <@1234>, see `go run .` and **this** <a:dance:5678>"
+
+	meta := MessageMetadata{}
+	meta.New(message)
+
+	if meta.String() != message {
+		t.Errorf("Expected %s\n Got %s", message, meta.String())
+	}
+}
+
+func TestUnicodeCreateWordMetadata(t *testing.T) {
+	tests := []struct {
+		input    string
+		word     string
+		expected *WordMetadata
+	}{
+		{"Café", "café", &WordMetadata{Capitalization: 1}},
+		// Decomposed diaeresis.
+		{"naïve,", "naïve", &WordMetadata{PostPunc: ","}},
+		{"“Ñandú”", "ñandú", &WordMetadata{Capitalization: 1, PrePunc: "“", PostPunc: "”"}},
+		{"o’clock", "o’clock", nil},
+		{"Αθήνα!", "αθήνα", &WordMetadata{Capitalization: 1, PostPunc: "!"}},
+		{"МОСКВА", "москва", &WordMetadata{Capitalization: 2}},
+		{"ǅemal", "ǆemal", &WordMetadata{Capitalization: 1}},
+		{"नमस्ते।", "नमस्ते", &WordMetadata{PostPunc: "।"}},
+		{"「東京」", "東京", &WordMetadata{PrePunc: "「", PostPunc: "」"}},
+	}
+
+	for _, test := range tests {
+		meta, word := createWordMetadata(test.input)
+
+		if !cmp.Equal(meta, test.expected) {
+			t.Errorf("%s: Expected: %+v\n Got: %+v\n", test.input, test.expected, meta)
+		}
+
+		if word != test.word {
+			t.Errorf("Expected \"%s\", got: %s", test.word, word)
+		}
+	}
+}
+
+func TestUnicodeGenerateMetadataFromSentence(t *testing.T) {
+	meta := MessageMetadata{}
+	meta.New("Ünter-städtische Straße, café-au-lait")
+
+	expected := []string{"ünter", "städtische", "straße", "café", "au", "lait"}
+	if !cmp.Equal(meta.Words, expected) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected, meta.Words)
+	}
+}
+
+func TestUnicodeIntegration(t *testing.T) {
+	for _, message := range []string{
+		"«Ça va?» dit Œdipe.",
+		"ǅemal and ǅenana",
+		"ΚΑΛΗΜΕΡΑ, κόσμε!",
+		"“Don’t,” she said.",
+	} {
+		meta := MessageMetadata{}
+		meta.New(message)
+
+		if meta.String() != message {
+			t.Errorf("Expected %s\n Got %s", message, meta.String())
+		}
+	}
+}
+
+func TestWhitespaceGenerateMetadataFromSentence(t *testing.T) {
+	meta := MessageMetadata{}
+	meta.New("  Two  spaces,\ttab\n\nwell-known")
+
+	expected := &MessageMetadata{
+		Words:    []string{"two", "spaces", "tab", "well", "known"},
+		Spacing:  []string{"  ", "\t", "\n\n", "", ""},
+		Metadata: []*WordMetadata{{Capitalization: 1}, {PostPunc: ","}, nil, {PostPunc: "-"}, nil},
+	}
+
+	if !cmp.Equal(meta.Words, expected.Words) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected.Words, meta.Words)
+	}
+
+	if !cmp.Equal(meta.Metadata, expected.Metadata) {
+		t.Errorf("Expected: %+v\n Got: %+v\n", expected.Metadata, meta.Metadata)
+	}
+
+	if !cmp.Equal(meta.Spacing, expected.Spacing) {
+		t.Errorf("Expected: %q\n Got: %q\n", expected.Spacing, meta.Spacing)
+	}
+
+	if meta.leading != "  " {
+		t.Errorf("Expected leading %q\n Got %q", "  ", meta.leading)
+	}
+}
+
+func TestWhitespaceIntegration(t *testing.T) {
+	for _, message := range []string{
+		"Hyphenated-words and middle$#!punctuation.",
+		"Double  spaced.  Sentences.",
+		"Shopping list:\n\n- eggs\n-   milk\n\t* butter",
+		"  indented\r\n  block  \n",
+		"\n\n",
+	} {
+		meta := MessageMetadata{}
+		meta.New(message)
+
+		if meta.String() != message {
+			t.Errorf("Expected %q\n Got %q", message, meta.String())
+		}
+	}
+}
+
+func TestMixedCaseCreateWordMetadata(t *testing.T) {
+	tests := []struct {
+		input    string
+		word     string
+		expected *WordMetadata
+	}{
+		{"iPhone", "iphone", &WordMetadata{
+			Capitalization: 4,
+			CaseMask:       []bool{false, true, false, false, false, false},
+			MaskedWord:     "iphone",
+		}},
+		{"McDonald,", "mcdonald", &WordMetadata{
+			Capitalization: 4,
+			CaseMask:       []bool{true, false, true, false, false, false, false, false},
+			MaskedWord:     "mcdonald",
+			PostPunc:       ",",
+		}},
+		{"camelCase", "camelcase", &WordMetadata{
+			Capitalization: 4,
+			CaseMask:       []bool{false, false, false, false, false, true, false, false, false},
+			MaskedWord:     "camelcase",
+		}},
+		{"I", "i", &WordMetadata{Capitalization: 1}},
+		{"MP3", "mp3", &WordMetadata{Capitalization: 2}},
+	}
+
+	for _, test := range tests {
+		meta, word := createWordMetadata(test.input)
+
+		if !cmp.Equal(meta, test.expected) {
+			t.Errorf("%s: Expected: %+v\n Got: %+v\n", test.input, test.expected, meta)
+		}
+
+		if word != test.word {
+			t.Errorf("Expected \"%s\", got: %s", test.word, word)
+		}
+	}
+}
+
+func TestCapitalizeReplacement(t *testing.T) {
+	tests := []struct {
+		message     string
+		idx         int
+		replacement string
+		expected    string
+	}{
+		// The mask is only kept for the word it was taken from.
+		{"my iPhone", 1, "iphone", "iPhone"},
+		{"my iPhone", 1, "mobile", "mobile"},
+		{"iPhone rules", 0, "mobile", "Mobile"},
+		{"my McDonald", 1, "burgers", "Burgers"},
+		{"my iPhone", 1, "cellphone", "cellphone"},
+		{"iPhone rules", 0, "cellphone", "Cellphone"},
+		{"at McDonald's", 1, "burger joint", "Burger joint"},
+		// Capitalized words are names or titles unless they start a sentence.
+		{"Ice is cold", 0, "frozen water", "Frozen water"},
+		{"I hate Ice", 2, "frozen water", "Frozen Water"},
+		{"Stop. Ice is cold", 1, "frozen water", "Frozen water"},
+		{"\"Stop!\" Ice is cold", 1, "frozen water", "Frozen water"},
+		{"stop\nIce is cold", 1, "frozen water", "Frozen water"},
+		{"STOP", 0, "halt", "HALT"},
+	}
+
+	for _, test := range tests {
+		meta := MessageMetadata{}
+		meta.New(test.message)
+
+		if result := meta.capitalize(test.replacement, test.idx); result != test.expected {
+			t.Errorf("%s: Expected %s\n Got: %s\n", test.message, test.expected, result)
+		}
+	}
+}
+
+func TestTitleCaseJoin(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected capitalization
+	}{
+		{"Ice Cream", titleCapitalized},
+		{"ICE CREAM", allCapitalized},
+		{"Ice cream", firstCapitzlized},
+		{"ice Cream", noCapitalization},
+	}
+
+	for _, test := range tests {
+		meta := MessageMetadata{}
+		meta.New(test.message)
+		meta.join(0, 2)
+
+		if c := meta.Metadata[0].Capitalization; c != test.expected {
+			t.Errorf("%s: Expected %d\n Got: %d\n", test.message, test.expected, c)
+		}
+	}
+}
+
+func TestCliticCreateWordMetadata(t *testing.T) {
+	tests := []struct {
+		input    string
+		word     string
+		expected *WordMetadata
+	}{
+		{"don't", "do", &WordMetadata{Clitic: "n't"}},
+		{"Don’t", "do", &WordMetadata{Capitalization: 1, Clitic: "n’t"}},
+		{"it's", "it", &WordMetadata{Clitic: "'s"}},
+		{"John's,", "john", &WordMetadata{Capitalization: 1, Clitic: "'s", PostPunc: ","}},
+		{"WE'RE", "we", &WordMetadata{Capitalization: 2, Clitic: "'RE"}},
+		{"they'll", "they", &WordMetadata{Clitic: "'ll"}},
+		{"I've", "i", &WordMetadata{Capitalization: 1, Clitic: "'ve"}},
+		{"dogs'.", "dogs", &WordMetadata{Clitic: "'", PostPunc: "."}},
+		{"can't", "can't", nil},
+		{"won't", "won't", nil},
+		{"let's", "let's", nil},
+		{"Let’s", "let’s", &WordMetadata{Capitalization: 1}},
+		{"'yes'", "yes", &WordMetadata{PrePunc: "'", PostPunc: "'"}},
+		{"‘dogs’", "dogs", &WordMetadata{PrePunc: "‘", PostPunc: "’"}},
+		{"'hello'", "hello", &WordMetadata{PrePunc: "'", PostPunc: "'"}},
+	}
+
+	for _, test := range tests {
+		meta, word := createWordMetadata(test.input)
+
+		if !cmp.Equal(meta, test.expected) {
+			t.Errorf("%s: Expected: %+v\n Got: %+v\n", test.input, test.expected, meta)
+		}
+
+		if word != test.word {
+			t.Errorf("Expected \"%s\", got: %s", test.word, word)
+		}
+	}
+}
+
+func TestAttachClitic(t *testing.T) {
+	tests := []struct {
+		word     string
+		clitic   string
+		expected string
+	}{
+		{"does", "n't", "doesn't"},
+		{"toilet", "'s", "toilet's"},
+		{"hounds", "'", "hounds'"},
+		{"mice", "’", "mice’s"},
+		{"MICE", "'", "MICE'S"},
+		{"word", "", "word"},
+	}
+
+	for _, test := range tests {
+		if result := attachClitic(test.word, test.clitic); result != test.expected {
+			t.Errorf("Expected %s\n Got %s", test.expected, result)
+		}
+	}
+}
+
+func TestQuotedPluralPossessive(t *testing.T) {
+	tests := []struct {
+		message string
+		clitic  string
+	}{
+		{"'they said dogs'", ""},
+		{"‘they said dogs’", ""},
+		{"'they' said dogs'", "'"},
+		{"they said dogs'", "'"},
+	}
+
+	for _, test := range tests {
+		meta := MessageMetadata{}
+		meta.New(test.message)
+
+		last := meta.Metadata[len(meta.Metadata)-1]
+		if last.Clitic != test.clitic {
+			t.Errorf("%s: Expected clitic %q\n Got %q", test.message, test.clitic, last.Clitic)
+		}
+
+		if meta.String() != test.message {
+			t.Errorf("Expected %s\n Got %s", test.message, meta.String())
+		}
+	}
+}
+
+func TestCliticIntegration(t *testing.T) {
+	for _, message := range []string{
+		"Don't worry, it's John's and the dogs' idea.",
+		"WE'RE sure they’ll say I've been right.",
+	} {
+		meta := MessageMetadata{}
+		meta.New(message)
+
+		if meta.String() != message {
+			t.Errorf("Expected %s\n Got %s", message, meta.String())
+		}
+	}
+}
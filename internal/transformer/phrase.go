@@ -0,0 +1,64 @@
+package transformer
+
+import (
+	"strings"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+)
+
+// Longest phrase in words that is looked up in the thesaurus.
+const maxPhraseWords = 4
+
+// phrasesAt returns every phrase of at least two adjacent words starting at
+// idx, shortest first. Phrases never span punctuation, clitics or verbatim
+// words, and only span words separated by a single space.
+func (m MessageMetadata) phrasesAt(idx int) []string {
+	var phrases []string
+
+	for end := idx; end < len(m.Words) && end-idx < maxPhraseWords; end++ {
+		if m.Words[end] == "" || m.verbatim(end) {
+			break
+		}
+
+		if end > idx {
+			if meta := m.Metadata[end]; meta != nil && meta.PrePunc != "" {
+				break
+			}
+
+			phrases = append(phrases, strings.Join(m.Words[idx:end+1], " "))
+		}
+
+		if meta := m.Metadata[end]; meta != nil && (meta.PostPunc != "" || meta.Clitic != "") || m.spacing(end) != " " {
+			break
+		}
+	}
+
+	return phrases
+}
+
+// allIgnored reports whether every word of a phrase is in the list of words
+// to ignore.
+func allIgnored(phrase string) bool {
+	for _, word := range strings.Split(phrase, " ") {
+		if _, ok := ignoreWords[word]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchPhrases joins the longest phrases found in the thesaurus into single
+// words, scanning the message from left to right.
+func matchPhrases(m *MessageMetadata, sets map[string]database.SynonymSets) {
+	for idx := 0; idx < len(m.Words); idx++ {
+		phrases := m.phrasesAt(idx)
+
+		for i := len(phrases) - 1; i >= 0; i-- {
+			if len(readings(phrases[i], sets)) > 0 {
+				m.join(idx, idx+i+2)
+				break
+			}
+		}
+	}
+}
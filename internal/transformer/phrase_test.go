@@ -0,0 +1,57 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+var phraseStore = lexemeStore{
+	synonyms: map[string]map[database.Lexeme]string{
+		"ice cream":   {database.Noun: "gelato"},
+		"ice":         {database.Noun: "frost"},
+		"cream":       {database.Noun: "lotion"},
+		"give up":     {database.Verb: "surrender"},
+		"in spite of": {database.Noun: "despite"},
+		"rain":        {database.Noun: "drizzle"},
+	},
+}
+
+func TestPhrasesAt(t *testing.T) {
+	m := MessageMetadata{}
+	m.New("we ate ice cream, and (more) cake")
+
+	tests := map[int][]string{
+		0: {"we ate", "we ate ice", "we ate ice cream"},
+		2: {"ice cream"},
+		3: nil,
+		4: nil,
+		// Punctuation on either side of a word interrupts the phrase.
+		5: nil,
+	}
+
+	for idx, expected := range tests {
+		if phrases := m.phrasesAt(idx); !cmp.Equal(phrases, expected) {
+			t.Errorf("Phrases at %d\n Expected: %+v\n Got: %+v\n", idx, expected, phrases)
+		}
+	}
+}
+
+func TestTransformPhrases(t *testing.T) {
+	tests := map[string]string{
+		"I love ice cream!":         "I love gelato!",
+		"Ice Cream and rain":        "Gelato and drizzle",
+		"They GAVE UP":              "They SURRENDERED",
+		"In spite of the rain.":     "Despite the drizzle.",
+		"ice, cream":                "frost, lotion",
+		"We had ice creams earlier": "We had gelatos earlier",
+	}
+
+	for message, expected := range tests {
+		if result := Transform(context.Background(), message, phraseStore, Options{}); result != expected {
+			t.Errorf("Expected %s\n Got %s", expected, result)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package transformer
+
+import (
+	"context"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+)
+
+// chainable reports whether words reached through relation can be followed
+// further through the same relation. Walking generic terms makes words ever
+// vaguer (dog, animal, organism) and walking specific terms the opposite.
+func chainable(relation database.Relation) bool {
+	return relation == database.GenericTerm || relation == database.SpecificTerm
+}
+
+// relatedSets looks up the words related to every word by relation. Chainable
+// relations are followed up to depth steps, each step replacing the words of
+// a lexeme with the words related to them. A word keeps the words of the last
+// step that reached anything, so words near the end of a chain still change.
+func relatedSets(ctx context.Context, db database.SynonymStore, words []string, relation database.Relation, depth int) (map[string]database.SynonymSets, error) {
+	sets, err := db.GetRelatedSets(ctx, words, relation)
+	if err != nil || !chainable(relation) {
+		return sets, err
+	}
+
+	for step := 1; step < depth; step++ {
+		var (
+			next = make([]string, 0, len(sets))
+			seen = make(map[string]struct{})
+		)
+
+		for _, wordSets := range sets {
+			for _, synonyms := range wordSets {
+				for _, s := range synonyms {
+					if _, ok := seen[s.Word]; !ok {
+						seen[s.Word] = struct{}{}
+						next = append(next, s.Word)
+					}
+				}
+			}
+		}
+
+		if len(next) == 0 {
+			break
+		}
+
+		further, err := db.GetRelatedSets(ctx, next, relation)
+		if err != nil {
+			return sets, err
+		}
+
+		// The sets may be shared with a cache, so new sets are built rather
+		// than changing them.
+		walked := make(map[string]database.SynonymSets, len(sets))
+		for word, wordSets := range sets {
+			walked[word] = make(database.SynonymSets, len(wordSets))
+			for lexeme, synonyms := range wordSets {
+				walked[word][lexeme] = synonyms
+				if deeper := walkStep(synonyms, further, lexeme); len(deeper) > 0 {
+					walked[word][lexeme] = deeper
+				}
+			}
+		}
+
+		sets = walked
+	}
+
+	return sets, nil
+}
+
+// walkStep returns the words of the given lexeme related to any of synonyms.
+// Weights of the words multiply along the way and words reached more than
+// once keep their highest weight.
+func walkStep(synonyms []database.Synonym, further map[string]database.SynonymSets, lexeme database.Lexeme) []database.Synonym {
+	var (
+		deeper []database.Synonym
+		index  = make(map[string]int)
+	)
+
+	for _, s := range synonyms {
+		for _, f := range further[s.Word][lexeme] {
+			weight := s.Weight * f.Weight
+			if i, ok := index[f.Word]; ok {
+				if weight > deeper[i].Weight {
+					deeper[i].Weight = weight
+				}
+
+				continue
+			}
+
+			index[f.Word] = len(deeper)
+			deeper = append(deeper, database.Synonym{Word: f.Word, Weight: weight})
+		}
+	}
+
+	return deeper
+}
@@ -0,0 +1,73 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+)
+
+// relationStore is a SynonymStore of nouns related to each other.
+type relationStore struct {
+	staticStore
+	related map[database.Relation]map[string][]string
+}
+
+func (s relationStore) GetRelatedSets(ctx context.Context, words []string, relation database.Relation) (map[string]database.SynonymSets, error) {
+	results := make(map[string]database.SynonymSets, len(words))
+	for _, word := range words {
+		sets := database.SynonymSets{}
+		for _, related := range s.related[relation][word] {
+			sets[database.Noun] = append(sets[database.Noun], database.Synonym{Word: related, Weight: database.DefaultWeight})
+		}
+
+		results[word] = sets
+	}
+
+	return results, nil
+}
+
+var hierarchyStore = relationStore{
+	related: map[database.Relation]map[string][]string{
+		database.GenericTerm: {
+			"dog":       {"canine"},
+			"canine":    {"carnivore"},
+			"carnivore": {"animal"},
+		},
+		database.SpecificTerm: {
+			"animal":    {"carnivore"},
+			"carnivore": {"canine"},
+			"canine":    {"dog"},
+		},
+		database.RelatedTerm: {
+			"dog": {"leash"},
+		},
+	},
+}
+
+func TestTransformRelationDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		relation database.Relation
+		depth    int
+		expected string
+	}{
+		{"The dog", database.GenericTerm, 0, "The canine"},
+		{"The dog", database.GenericTerm, 1, "The canine"},
+		{"The dog", database.GenericTerm, 2, "The carnivore"},
+		// Walking stops at the end of the chain.
+		{"The dog", database.GenericTerm, 10, "The animal"},
+		{"Two dogs", database.GenericTerm, 3, "Two animals"},
+		{"The animal", database.SpecificTerm, 3, "The dog"},
+		{"The dog", database.SpecificTerm, 2, "The dog"},
+		// Related terms aren't chained.
+		{"The dog", database.RelatedTerm, 3, "The leash"},
+	}
+
+	for _, test := range tests {
+		result := Transform(context.Background(), test.input, hierarchyStore, Options{Relation: test.relation, Depth: test.depth})
+		if result != test.expected {
+			t.Errorf("Expected %s\n Got %s", test.expected, result)
+		}
+	}
+}
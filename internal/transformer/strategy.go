@@ -0,0 +1,151 @@
+package transformer
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+)
+
+// Choice describes a word of a message that is about to be replaced.
+type Choice struct {
+	// Word is the base form of the word being replaced.
+	Word string
+	// Previous is the word before it in the transformed message, or empty at
+	// the start of the message.
+	Previous string
+	// Candidates are the words it can be replaced with. It is never empty.
+	Candidates []database.Synonym
+	// Absurdity selects how obscure a randomly chosen candidate is.
+	Absurdity database.Absurdity
+}
+
+// Strategy chooses which candidate replaces a word of a message. The chosen
+// word is inflected like the word it replaces afterwards.
+type Strategy interface {
+	Choose(c Choice) string
+}
+
+// StrategyFunc adapts an ordinary function to a Strategy.
+type StrategyFunc func(c Choice) string
+
+// Choose calls f(c).
+func (f StrategyFunc) Choose(c Choice) string {
+	return f(c)
+}
+
+// Built-in strategies. Strategies that prefer some candidates over others pick
+// randomly between equally good candidates.
+var (
+	// Random samples a candidate by weight and absurdity.
+	Random Strategy = StrategyFunc(chooseRandom)
+	// Longest prefers the candidates with the most letters.
+	Longest Strategy = preferring(func(c Choice, s database.Synonym) int {
+		return utf8.RuneCountInString(s.Word)
+	})
+	// Shortest prefers the candidates with the fewest letters.
+	Shortest Strategy = preferring(func(c Choice, s database.Synonym) int {
+		return -utf8.RuneCountInString(s.Word)
+	})
+	// MostSyllables prefers the candidates with the most syllables.
+	MostSyllables Strategy = preferring(func(c Choice, s database.Synonym) int {
+		return countSyllables(s.Word)
+	})
+	// Alliterative prefers candidates starting with the same letter as the
+	// previous word.
+	Alliterative Strategy = preferring(func(c Choice, s database.Synonym) int {
+		first, _ := utf8.DecodeRuneInString(s.Word)
+		if previous, _ := utf8.DecodeRuneInString(c.Previous); c.Previous != "" && first == previous {
+			return 1
+		}
+
+		return 0
+	})
+	// Rhyming prefers candidates that rhyme with the word they replace.
+	Rhyming Strategy = preferring(func(c Choice, s database.Synonym) int {
+		if s.Word != c.Word && rhyme(s.Word) == rhyme(c.Word) {
+			return 1
+		}
+
+		return 0
+	})
+)
+
+var strategies = map[string]Strategy{
+	"random":       Random,
+	"longest":      Longest,
+	"shortest":     Shortest,
+	"syllables":    MostSyllables,
+	"alliterative": Alliterative,
+	"rhyming":      Rhyming,
+}
+
+// ParseStrategy returns the built-in strategy with the given name.
+func ParseStrategy(name string) (Strategy, error) {
+	if s, ok := strategies[strings.ToLower(name)]; ok {
+		return s, nil
+	}
+
+	return nil, fmt.Errorf("unknown strategy '%s'", name)
+}
+
+func chooseRandom(c Choice) string {
+	word, _ := c.Absurdity.Sample(c.Candidates)
+	return word
+}
+
+// preferring returns a Strategy randomly choosing between the candidates with
+// the highest score.
+func preferring(score func(c Choice, s database.Synonym) int) Strategy {
+	return StrategyFunc(func(c Choice) string {
+		var (
+			best      []database.Synonym
+			bestScore int
+		)
+
+		for _, s := range c.Candidates {
+			switch n := score(c, s); {
+			case len(best) == 0 || n > bestScore:
+				best, bestScore = []database.Synonym{s}, n
+			case n == bestScore:
+				best = append(best, s)
+			}
+		}
+
+		c.Candidates = best
+
+		return chooseRandom(c)
+	})
+}
+
+// rhyme returns the part of a word that has to match for another word to
+// rhyme with it: its last group of vowels and everything after them. A
+// silent final e doesn't count as a vowel group of its own.
+func rhyme(word string) string {
+	runes := []rune(word)
+
+	end := len(runes)
+	if end > 2 && runes[end-1] == 'e' && !isVowelRune(runes[end-2]) {
+		end--
+	}
+
+	vowel := func(i int) bool {
+		return isVowelRune(runes[i]) || (runes[i] == 'y' && i > 0)
+	}
+
+	i := end - 1
+	for i >= 0 && !vowel(i) {
+		i--
+	}
+
+	for i > 0 && vowel(i-1) {
+		i--
+	}
+
+	if i < 0 {
+		return word
+	}
+
+	return string(runes[i:])
+}
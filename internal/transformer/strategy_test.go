@@ -0,0 +1,113 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+)
+
+func candidates(words ...string) []database.Synonym {
+	synonyms := make([]database.Synonym, len(words))
+	for i, word := range words {
+		synonyms[i] = database.Synonym{Word: word, Weight: database.DefaultWeight}
+	}
+
+	return synonyms
+}
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy Strategy
+		choice   Choice
+		expected string
+	}{
+		{
+			name:     "longest",
+			strategy: Longest,
+			choice:   Choice{Word: "big", Candidates: candidates("large", "huge", "enormous")},
+			expected: "enormous",
+		},
+		{
+			name:     "shortest",
+			strategy: Shortest,
+			choice:   Choice{Word: "big", Candidates: candidates("large", "huge", "enormous")},
+			expected: "huge",
+		},
+		{
+			name:     "syllables",
+			strategy: MostSyllables,
+			choice:   Choice{Word: "big", Candidates: candidates("gigantic", "enormous", "elephantine")},
+			expected: "elephantine",
+		},
+		{
+			name:     "syllables accented",
+			strategy: MostSyllables,
+			choice:   Choice{Word: "coffee", Candidates: candidates("café", "joe")},
+			expected: "café",
+		},
+		{
+			name:     "alliterative",
+			strategy: Alliterative,
+			choice:   Choice{Word: "dog", Previous: "big", Candidates: candidates("hound", "beagle", "mutt")},
+			expected: "beagle",
+		},
+		{
+			name:     "rhyming",
+			strategy: Rhyming,
+			choice:   Choice{Word: "cat", Candidates: candidates("feline", "kitty", "mat", "cat")},
+			expected: "mat",
+		},
+		{
+			name:     "rhyming silent e",
+			strategy: Rhyming,
+			choice:   Choice{Word: "make", Candidates: candidates("create", "bake", "form")},
+			expected: "bake",
+		},
+		{
+			name:     "rhyming accented",
+			strategy: Rhyming,
+			choice:   Choice{Word: "café", Candidates: candidates("safe", "olé")},
+			expected: "olé",
+		},
+	}
+
+	for _, test := range tests {
+		// Strategies are random between equally good candidates, so repeat
+		// them a few times.
+		for i := 0; i < 10; i++ {
+			if result := test.strategy.Choose(test.choice); result != test.expected {
+				t.Errorf("%s: Expected %s\n Got %s", test.name, test.expected, result)
+				break
+			}
+		}
+	}
+}
+
+func TestStrategyFallback(t *testing.T) {
+	choice := Choice{Word: "dog", Previous: "the", Candidates: candidates("hound")}
+
+	if result := Alliterative.Choose(choice); result != "hound" {
+		t.Errorf("Expected hound\n Got %s", result)
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	if s, err := ParseStrategy("Longest"); err != nil || s == nil {
+		t.Errorf("Expected longest strategy\n Got %v, %v", s, err)
+	}
+
+	if _, err := ParseStrategy("sideways"); err == nil {
+		t.Error("Expected error for unknown strategy")
+	}
+}
+
+func TestTransformStrategy(t *testing.T) {
+	store := staticStore{"big": {"large", "enormous"}, "dog": {"hound", "mutt"}}
+
+	result := Transform(context.Background(), "Big dog", store, Options{Strategy: Longest})
+	if expected := "Enormous hound"; result != expected {
+		t.Errorf("Expected %s\n Got %s", expected, result)
+	}
+}
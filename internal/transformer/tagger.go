@@ -0,0 +1,236 @@
+package transformer
+
+import (
+	"strings"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+)
+
+// Word classes used by the part of speech tagger. Closed word classes are
+// recognised from the lists below, everything else is an open class word
+// whose lexeme is guessed from its context and suffix.
+type wordClass int
+
+const (
+	openClass wordClass = iota
+	determinerClass
+	subjectPronounClass
+	objectPronounClass
+	modalClass
+	auxiliaryClass
+	beClass
+	intensifierClass
+	prepositionClass
+	conjunctionClass
+)
+
+var closedClasses = map[wordClass][]string{
+	determinerClass: {
+		"a", "an", "the", "this", "that", "these", "those", "my", "your", "his",
+		"her", "its", "our", "their", "some", "any", "every", "each", "no",
+		"another", "many", "much", "few", "several", "all", "both",
+	},
+	subjectPronounClass: {"i", "you", "he", "she", "it", "we", "they", "who"},
+	objectPronounClass:  {"me", "him", "us", "them"},
+	modalClass: {
+		"can", "could", "will", "would", "shall", "should", "may", "might",
+		"must", "to", "not", "won't", "can't", "do", "does", "did", "let's",
+	},
+	auxiliaryClass: {"have", "has", "had"},
+	beClass: {
+		"am", "is", "are", "was", "were", "be", "been", "being", "seem", "seems",
+		"seemed", "become", "becomes", "became", "feel", "feels", "felt",
+	},
+	intensifierClass: {
+		"very", "really", "too", "so", "quite", "rather", "extremely", "pretty",
+		"fairly", "incredibly", "more", "most", "less", "least",
+	},
+	prepositionClass: {
+		"in", "on", "at", "by", "for", "with", "about", "from", "into", "onto",
+		"over", "under", "of", "through", "between", "after", "before", "during",
+		"without", "within", "against", "among", "around", "behind", "near",
+	},
+	conjunctionClass: {"and", "or", "but", "nor", "yet", "because", "if", "when", "while"},
+}
+
+var wordClasses = func() map[string]wordClass {
+	classes := make(map[string]wordClass)
+	for class, words := range closedClasses {
+		for _, word := range words {
+			classes[word] = class
+		}
+	}
+
+	return classes
+}()
+
+// Suffixes that strongly suggest a lexeme for words whose context doesn't.
+var suffixLexemes = []struct {
+	suffix string
+	lexeme database.Lexeme
+}{
+	{"ly", database.Adverb},
+	{"ness", database.Noun},
+	{"ment", database.Noun},
+	{"tion", database.Noun},
+	{"sion", database.Noun},
+	{"ity", database.Noun},
+	{"ship", database.Noun},
+	{"ance", database.Noun},
+	{"ence", database.Noun},
+	{"ism", database.Noun},
+	{"ous", database.Adjective},
+	{"ful", database.Adjective},
+	{"less", database.Adjective},
+	{"able", database.Adjective},
+	{"ible", database.Adjective},
+	{"ive", database.Adjective},
+	{"ic", database.Adjective},
+	{"ish", database.Adjective},
+	{"ize", database.Verb},
+	{"ise", database.Verb},
+	{"ify", database.Verb},
+}
+
+// posTag is the lexeme the tagger settled on for a word. Words the tagger is
+// unsure about are not tagged.
+type posTag struct {
+	lexeme database.Lexeme
+	tagged bool
+}
+
+// tagWords guesses the lexeme every word of a message is used as. The synonym
+// sets of the words act as the lexicon: a word is only tagged with the most
+// likely lexeme it has synonyms for. Punctuation or a line break after a word
+// ends the current clause.
+func tagWords(m MessageMetadata, sets map[string]database.SynonymSets) []posTag {
+	tags := make([]posTag, len(m.Words))
+
+	var (
+		prevClass = conjunctionClass
+		prevTag   posTag
+	)
+
+	for idx, word := range m.Words {
+		class := wordClasses[word]
+		clauseEnd := idx == len(m.Words)-1 || m.endsClause(idx)
+
+		if class == openClass {
+			next := nextWordClass(m, idx)
+			for _, lexeme := range guessLexemes(word, prevClass, prevTag, next, clauseEnd) {
+				if _, known := sets[word][lexeme]; known {
+					tags[idx] = posTag{lexeme: lexeme, tagged: true}
+					break
+				}
+			}
+		}
+
+		prevClass, prevTag = class, tags[idx]
+		if clauseEnd {
+			prevClass, prevTag = conjunctionClass, posTag{}
+		}
+	}
+
+	return tags
+}
+
+// guessLexemes returns the lexemes a word is likely used as, most likely
+// first. Contextual rules take priority over suffix rules. Nothing is
+// returned if the tagger is unsure.
+func guessLexemes(word string, prev wordClass, prevTag posTag, next wordClass, clauseEnd bool) []database.Lexeme {
+	var (
+		suffix, hasSuffix = suffixLexeme(word)
+		adverb            = hasSuffix && suffix == database.Adverb
+		// An open class word follows in the same clause, so this one is
+		// likely to modify it.
+		modifies = !clauseEnd && next == openClass
+	)
+
+	switch prev {
+	case determinerClass:
+		if modifies {
+			return []database.Lexeme{database.Adjective, database.Noun}
+		}
+
+		return []database.Lexeme{database.Noun}
+	case subjectPronounClass, modalClass:
+		if adverb {
+			return []database.Lexeme{database.Adverb, database.Verb}
+		}
+
+		return []database.Lexeme{database.Verb}
+	case auxiliaryClass:
+		return []database.Lexeme{database.Verb}
+	case beClass:
+		switch {
+		case strings.HasSuffix(word, "ing"), strings.HasSuffix(word, "ed"):
+			return []database.Lexeme{database.Verb, database.Adjective}
+		case adverb:
+			return []database.Lexeme{database.Adverb, database.Adjective}
+		default:
+			return []database.Lexeme{database.Adjective, database.Noun}
+		}
+	case intensifierClass:
+		if adverb {
+			return []database.Lexeme{database.Adverb, database.Adjective}
+		}
+
+		return []database.Lexeme{database.Adjective, database.Adverb}
+	case prepositionClass:
+		if modifies {
+			return []database.Lexeme{database.Adjective, database.Noun}
+		}
+
+		return []database.Lexeme{database.Noun}
+	}
+
+	if hasSuffix {
+		return []database.Lexeme{suffix}
+	}
+
+	if prevTag.tagged {
+		switch prevTag.lexeme {
+		case database.Adjective:
+			if modifies {
+				return []database.Lexeme{database.Adjective, database.Noun}
+			}
+
+			return []database.Lexeme{database.Noun}
+		case database.Verb:
+			// A lone word ending the clause after a verb usually modifies
+			// it, otherwise it's the object of the verb.
+			if clauseEnd {
+				return []database.Lexeme{database.Adverb, database.Noun}
+			}
+
+			return []database.Lexeme{database.Noun}
+		}
+	}
+
+	return nil
+}
+
+func suffixLexeme(word string) (database.Lexeme, bool) {
+	for _, s := range suffixLexemes {
+		if len(word) > len(s.suffix)+2 && strings.HasSuffix(word, s.suffix) {
+			return s.lexeme, true
+		}
+	}
+
+	return 0, false
+}
+
+func nextWordClass(m MessageMetadata, idx int) wordClass {
+	if idx+1 < len(m.Words) {
+		return wordClasses[m.Words[idx+1]]
+	}
+
+	return conjunctionClass
+}
+
+// endsClause reports whether punctuation or a line break follows the word at
+// idx.
+func (m MessageMetadata) endsClause(idx int) bool {
+	meta := m.Metadata[idx]
+	return meta != nil && meta.PostPunc != "" || m.endsLine(idx)
+}
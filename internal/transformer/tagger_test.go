@@ -0,0 +1,149 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+	"github.com/google/go-cmp/cmp"
+)
+
+// lexemeStore is a SynonymStore with a single synonym and antonym per word
+// and lexeme.
+type lexemeStore struct {
+	staticStore
+	synonyms map[string]map[database.Lexeme]string
+	antonyms map[string]map[database.Lexeme]string
+}
+
+func (s lexemeStore) GetSynonymSets(ctx context.Context, words []string) (map[string]database.SynonymSets, error) {
+	return s.GetRelatedSets(ctx, words, database.Synonymous)
+}
+
+func (s lexemeStore) GetRelatedSets(ctx context.Context, words []string, relation database.Relation) (map[string]database.SynonymSets, error) {
+	var related map[string]map[database.Lexeme]string
+
+	switch relation {
+	case database.Synonymous:
+		related = s.synonyms
+	case database.Antonym:
+		related = s.antonyms
+	}
+
+	results := make(map[string]database.SynonymSets, len(words))
+	for _, word := range words {
+		sets := database.SynonymSets{}
+		for lexeme, synonym := range related[word] {
+			sets[lexeme] = []database.Synonym{{Word: synonym, Weight: database.DefaultWeight}}
+		}
+
+		results[word] = sets
+	}
+
+	return results, nil
+}
+
+var taggerStore = lexemeStore{
+	synonyms: map[string]map[database.Lexeme]string{
+		"run": {
+			database.Noun: "sprint",
+			database.Verb: "dash",
+		},
+		"fast": {
+			database.Noun:      "diet",
+			database.Verb:      "starve",
+			database.Adjective: "speedy",
+			database.Adverb:    "quickly",
+		},
+		"big": {
+			database.Adjective: "large",
+		},
+		"dog": {
+			database.Noun: "hound",
+			database.Verb: "tail",
+		},
+		"quietly": {
+			database.Adverb: "softly",
+		},
+		"happy": {
+			database.Noun:      "joy",
+			database.Adjective: "glad",
+		},
+	},
+}
+
+func TestTagWords(t *testing.T) {
+	tests := []struct {
+		message  string
+		expected []posTag
+	}{
+		{
+			message: "I run fast",
+			expected: []posTag{
+				{},
+				{lexeme: database.Verb, tagged: true},
+				{lexeme: database.Adverb, tagged: true},
+			},
+		},
+		{
+			message: "The big fast dog.",
+			expected: []posTag{
+				{},
+				{lexeme: database.Adjective, tagged: true},
+				{lexeme: database.Adjective, tagged: true},
+				{lexeme: database.Noun, tagged: true},
+			},
+		},
+		{
+			message: "A run, fast",
+			expected: []posTag{
+				{},
+				{lexeme: database.Noun, tagged: true},
+				{},
+			},
+		},
+		{
+			// "n't" is split off before tagging.
+			message: "We don't run",
+			expected: []posTag{
+				{},
+				{},
+				{lexeme: database.Verb, tagged: true},
+			},
+		},
+		{
+			message: "The dog is happy",
+			expected: []posTag{
+				{},
+				{lexeme: database.Noun, tagged: true},
+				{},
+				{lexeme: database.Adjective, tagged: true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		m := MessageMetadata{}
+		m.New(test.message)
+
+		sets, _ := taggerStore.GetSynonymSets(context.Background(), m.Words)
+
+		if tags := tagWords(m, sets); !cmp.Equal(tags, test.expected, cmp.AllowUnexported(posTag{})) {
+			t.Errorf("Tagging %q\n Expected: %+v\n Got: %+v\n", test.message, test.expected, tags)
+		}
+	}
+}
+
+func TestTransformPartOfSpeech(t *testing.T) {
+	tests := map[string]string{
+		"I run fast":            "I dash quickly",
+		"We go for a fast run.": "We go for a speedy sprint.",
+		"They run quietly":      "They dash softly",
+	}
+
+	for message, expected := range tests {
+		if result := Transform(context.Background(), message, taggerStore, Options{}); result != expected {
+			t.Errorf("Expected %s\n Got %s", expected, result)
+		}
+	}
+}
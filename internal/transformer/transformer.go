@@ -1,22 +1,178 @@
 package transformer
 
-import "github.com/MrFlynn/thesaurize/internal/database"
+import (
+	"context"
+	"log"
 
-// Transform takes a message and runs each word through the thesaurus.
-func Transform(message string, db database.Database, skipCommon bool) string {
+	"github.com/MrFlynn/thesaurize/internal/database"
+)
+
+// Options controls how a message is transformed.
+type Options struct {
+	// SkipCommon leaves common words such as articles unchanged.
+	SkipCommon bool
+	// Absurdity selects how obscure the chosen synonyms are.
+	Absurdity database.Absurdity
+	// Relation selects the words that replace the words of the message. The
+	// zero value replaces them with synonyms, Antonym with their opposites.
+	// Relations are applied while looking words up, before Strategy chooses
+	// between the words found.
+	Relation database.Relation
+	// Depth is how many steps are taken from each word along relations that
+	// can be chained, GenericTerm to vaguify a message and SpecificTerm to
+	// make it more specific. Values below 1 take a single step.
+	Depth int
+	// Strategy chooses between the words a word can be replaced with. Random
+	// is used if it is nil.
+	Strategy Strategy
+}
+
+// reading is one way of interpreting a word of a message: as an inflected
+// form of a base word along with the synonyms of the base word.
+type reading struct {
+	lemma
+	sets database.SynonymSets
+}
+
+// readings returns the ways a word can be interpreted given the synonym sets
+// of the word and its possible lemmas. The word itself comes first, followed
+// by its lemmas restricted to the lexemes their inflection allows.
+func readings(word string, sets map[string]database.SynonymSets) []reading {
+	var results []reading
+
+	if _, ok := sets[word].Lexeme(); ok {
+		results = append(results, reading{lemma: lemma{base: word}, sets: sets[word]})
+	}
+
+	for _, l := range lemmas(word) {
+		restricted := make(database.SynonymSets, len(l.lexemes))
+		for _, lexeme := range l.lexemes {
+			if synonyms := sets[l.base][lexeme]; len(synonyms) > 0 {
+				restricted[lexeme] = synonyms
+			}
+		}
+
+		if len(restricted) > 0 {
+			results = append(results, reading{lemma: l, sets: restricted})
+		}
+	}
+
+	return results
+}
+
+// Transform takes a message and runs each word through the thesaurus. All
+// words and phrases of adjacent words in the message, along with their
+// possible base forms, are looked up in a single batch, plus one for every
+// further step along chained relations. Lookups are abandoned once ctx is
+// done, leaving the remaining words unchanged. Each word is
+// replaced by a word with the relation given in opts and of the part of
+// speech it is used as, falling back to the lexeme ordering of the database
+// if that can't be determined. Words without any such related words are left
+// unchanged. Replacements of inflected words are inflected the same way. The
+// longest phrases found in the thesaurus are replaced as a whole.
+func Transform(ctx context.Context, message string, db database.SynonymStore, opts Options) string {
 	messageMeta := MessageMetadata{}
 	messageMeta.New(message)
 
+	lookup := make([]string, 0, len(messageMeta.Words))
+	add := func(word string) {
+		lookup = append(lookup, word)
+		for _, l := range lemmas(word) {
+			lookup = append(lookup, l.base)
+		}
+	}
+
 	for idx, word := range messageMeta.Words {
+		if messageMeta.verbatim(idx) {
+			continue
+		}
+
+		for _, phrase := range messageMeta.phrasesAt(idx) {
+			if !opts.SkipCommon || !allIgnored(phrase) {
+				add(phrase)
+			}
+		}
+
 		// Skip word if it's in a preconfigured list of words to ignore.
-		if skipCommon {
-			if _, ok := ignoreWords[word]; ok {
-				continue
+		if opts.SkipCommon && allIgnored(word) {
+			continue
+		}
+
+		add(word)
+	}
+
+	sets, err := relatedSets(ctx, db, lookup, opts.Relation, opts.Depth)
+	if err != nil {
+		log.Printf("Could not access datastore for %d words, %s", len(lookup), err)
+	}
+
+	matchPhrases(&messageMeta, sets)
+
+	var (
+		wordReadings = make(map[string][]reading, len(messageMeta.Words))
+		// Every lexeme a word can be used as, for the tagger.
+		lexicon = make(map[string]database.SynonymSets, len(messageMeta.Words))
+	)
+
+	for idx, word := range messageMeta.Words {
+		if messageMeta.verbatim(idx) || opts.SkipCommon && allIgnored(word) {
+			continue
+		}
+
+		if _, ok := sets[word]; !ok || wordReadings[word] != nil {
+			continue
+		}
+
+		wordReadings[word] = readings(word, sets)
+
+		lexicon[word] = make(database.SynonymSets)
+		for _, r := range wordReadings[word] {
+			for lexeme, synonyms := range r.sets {
+				lexicon[word][lexeme] = synonyms
 			}
 		}
+	}
 
-		messageMeta.Words[idx] = db.GetBestCandidateWord(word)
+	tags := tagWords(messageMeta, lexicon)
+
+	strategy := opts.Strategy
+	if strategy == nil {
+		strategy = Random
+	}
+
+	for idx, word := range messageMeta.Words {
+		if messageMeta.verbatim(idx) || messageMeta.negated(idx) {
+			continue
+		}
+
+		choice := Choice{Absurdity: opts.Absurdity}
+		if idx > 0 {
+			choice.Previous = messageMeta.Words[idx-1]
+		}
+
+		if candidate, ok := replace(wordReadings[word], tags[idx], choice, strategy); ok {
+			messageMeta.Words[idx] = candidate
+		}
 	}
 
 	return messageMeta.String()
 }
+
+// replace lets strategy choose a synonym for a word from the first reading
+// with synonyms of the tagged lexeme, or the first lexeme of the first reading
+// if the word wasn't tagged. The synonym is inflected like the word.
+func replace(readings []reading, tag posTag, choice Choice, strategy Strategy) (string, bool) {
+	for _, r := range readings {
+		lexeme, ok := tag.lexeme, tag.tagged
+		if !ok {
+			lexeme, ok = r.sets.Lexeme()
+		}
+
+		if candidates := r.sets[lexeme]; ok && len(candidates) > 0 {
+			choice.Word, choice.Candidates = r.base, candidates
+			return inflect(strategy.Choose(choice), r.inflection, lexeme), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,229 @@
+package transformer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MrFlynn/thesaurize/internal/database"
+)
+
+// staticStore is a SynonymStore that always returns the first synonym of a
+// word so that transformations are deterministic.
+type staticStore map[string][]string
+
+func (s staticStore) GetSynonyms(ctx context.Context, word string, lexeme database.Lexeme) ([]string, error) {
+	return s[word], nil
+}
+
+func (s staticStore) GetSynonymSets(ctx context.Context, words []string) (map[string]database.SynonymSets, error) {
+	results := make(map[string]database.SynonymSets, len(words))
+	for _, word := range words {
+		sets := database.SynonymSets{}
+		for _, syn := range s[word] {
+			sets[database.Noun] = append(sets[database.Noun], database.Synonym{Word: syn, Weight: database.DefaultWeight})
+		}
+
+		results[word] = sets
+	}
+
+	return results, nil
+}
+
+// GetRelatedSets only knows synonyms, every other relation is empty.
+func (s staticStore) GetRelatedSets(ctx context.Context, words []string, relation database.Relation) (map[string]database.SynonymSets, error) {
+	if relation == database.Synonymous {
+		return s.GetSynonymSets(ctx, words)
+	}
+
+	results := make(map[string]database.SynonymSets, len(words))
+	for _, word := range words {
+		results[word] = database.SynonymSets{}
+	}
+
+	return results, nil
+}
+
+func (s staticStore) AddSynonyms(ctx context.Context, entries []database.Entry) error {
+	for _, e := range entries {
+		for _, syn := range e.Synonyms {
+			s[e.Word] = append(s[e.Word], syn.Word)
+		}
+	}
+
+	return nil
+}
+
+func (s staticStore) SendReady(ctx context.Context, words int) (database.DatasetInfo, error) {
+	return database.DatasetInfo{Words: words}, nil
+}
+
+func (s staticStore) GetDatasetInfo(ctx context.Context) (database.DatasetInfo, error) {
+	return database.DatasetInfo{}, nil
+}
+
+func (s staticStore) WaitForReady(ctx context.Context, timeout int) error { return nil }
+func (s staticStore) Close() error                                        { return nil }
+
+func TestTransform(t *testing.T) {
+	store := staticStore{
+		"hello": {"hullo"},
+		"world": {"globe"},
+	}
+
+	result := Transform(context.Background(), "Hello, world!", store, Options{})
+	if result != "Hullo, globe!" {
+		t.Errorf("Expected %s\n Got %s", "Hullo, globe!", result)
+	}
+}
+
+func TestTransformSkipCommon(t *testing.T) {
+	store := staticStore{
+		"the":   {"thee"},
+		"world": {"globe"},
+	}
+
+	result := Transform(context.Background(), "The world", store, Options{SkipCommon: true})
+	if result != "The globe" {
+		t.Errorf("Expected %s\n Got %s", "The globe", result)
+	}
+}
+
+// batchCountingStore records every batch of words that is looked up.
+type batchCountingStore struct {
+	staticStore
+	batches [][]string
+}
+
+func (s *batchCountingStore) GetRelatedSets(ctx context.Context, words []string, relation database.Relation) (map[string]database.SynonymSets, error) {
+	s.batches = append(s.batches, words)
+	return s.staticStore.GetRelatedSets(ctx, words, relation)
+}
+
+func TestTransformSingleBatch(t *testing.T) {
+	store := &batchCountingStore{staticStore: staticStore{"buffalo": {"bison"}}}
+
+	result := Transform(context.Background(), "Buffalo buffalo buffalo", store, Options{})
+	if result != "Bison bison bison" {
+		t.Errorf("Expected %s\n Got %s", "Bison bison bison", result)
+	}
+
+	if len(store.batches) != 1 {
+		t.Errorf("Expected 1 batch\n Got %d", len(store.batches))
+	}
+}
+
+func TestTransformAntonyms(t *testing.T) {
+	store := lexemeStore{
+		synonyms: map[string]map[database.Lexeme]string{
+			"hot":  {database.Adjective: "warm"},
+			"fast": {database.Adjective: "speedy"},
+		},
+		antonyms: map[string]map[database.Lexeme]string{
+			"hot":  {database.Adjective: "cold"},
+			"slow": {database.Adjective: "fast"},
+			"big":  {database.Adjective: "small"},
+		},
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"It is hot", "It is cold"},
+		// Words without antonyms are left alone, even if they have synonyms.
+		{"It is fast", "It is fast"},
+		{"HOT and slow!", "COLD and fast!"},
+		{"The bigger dog", "The smaller dog"},
+	}
+
+	for _, test := range tests {
+		result := Transform(context.Background(), test.input, store, Options{Relation: database.Antonym})
+		if result != test.expected {
+			t.Errorf("Expected %s\n Got %s", test.expected, result)
+		}
+	}
+}
+
+func TestTransformMarkup(t *testing.T) {
+	store := staticStore{"run": {"sprint"}, "secret": {"mystery"}, "example": {"model"}, "wave": {"surge"}}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Run `go run .` <@1234>", "Sprint `go run .` <@1234>"},
+		{"A ||secret|| <:wave:5678>", "A ||mystery|| <:wave:5678>"},
+		{"See https://example.com/run", "See https://example.com/run"},
+		{"```\nrun\n```", "```\nrun\n```"},
+	}
+
+	for _, test := range tests {
+		result := Transform(context.Background(), test.input, store, Options{})
+		if result != test.expected {
+			t.Errorf("Expected %s\n Got %s", test.expected, result)
+		}
+	}
+}
+
+func TestTransformLayout(t *testing.T) {
+	store := staticStore{"eggs": {"ova"}, "milk": {"dairy"}, "ice cream": {"gelato"}}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Buy:\n\n- eggs\n-   milk\n", "Buy:\n\n- ova\n-   dairy\n"},
+		{"ice cream", "gelato"},
+		// Phrases don't span line breaks.
+		{"ice\ncream", "ice\ncream"},
+	}
+
+	for _, test := range tests {
+		result := Transform(context.Background(), test.input, store, Options{})
+		if result != test.expected {
+			t.Errorf("Expected %q\n Got %q", test.expected, result)
+		}
+	}
+}
+
+func TestTransformClitics(t *testing.T) {
+	store := staticStore{
+		"dog":   {"hound"},
+		"mouse": {"rodent"},
+		"john":  {"toilet"},
+		"they":  {"those"},
+		"do":    {"perform"},
+		"did":   {"had"},
+		"child": {"kid"},
+		"kid":   {"child"},
+		"yes":   {"yea"},
+		"let":   {"allow"},
+		"run":   {"sprint"},
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"The dog's bone", "The hound's bone"},
+		{"The dogs' bones", "The hounds' bones"},
+		{"John's car", "Toilet's car"},
+		{"They'll go", "Those'll go"},
+		// Auxiliaries with "n't" are never replaced.
+		{"I don't know", "I don't know"},
+		{"I didn't go", "I didn't go"},
+		{"The kids' toys", "The children's toys"},
+		// Closing quotes aren't possessives.
+		{"He said 'yes' to me", "He said 'yea' to me"},
+		{"He said 'ask the kids' first", "He said 'ask the children' first"},
+		// The "'s" of "let's" means "us".
+		{"let's run", "let's sprint"},
+	}
+
+	for _, test := range tests {
+		result := Transform(context.Background(), test.input, store, Options{})
+		if result != test.expected {
+			t.Errorf("Expected %s\n Got %s", test.expected, result)
+		}
+	}
+}